@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/export"
+	"github.com/leanix/leanix-k8s-connector/pkg/iris"
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+	"github.com/leanix/leanix-k8s-connector/pkg/utils"
+	flag "github.com/spf13/pflag"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// runExport maps the cluster of the kubeconfig with a local configuration and writes the inventory,
+// neither a LeanIX token nor Iris is needed. It returns the exit code of the command.
+func runExport(args []string) int {
+	flags := flag.NewFlagSet(utils.ExportCommand, flag.ContinueOnError)
+	kubeconfig := flags.String(utils.KubeconfigFlag, "", "kubeconfig file, defaults to $KUBECONFIG or ~/.kube/config")
+	kubeContext := flags.String(utils.ExportContextFlag, "", "context of the kubeconfig, defaults to the current context")
+	configPath := flags.String(utils.ExportConfigFlag, "", "YAML or JSON file with the configuration, e.g. {cluster: prod, discoveryMode: WORKLOAD}")
+	format := flags.String(utils.ExportFormatFlag, export.FormatJSON, "format of the inventory, 'json', 'jsonl', 'csv' or 'cyclonedx'")
+	output := flags.String(utils.ExportOutputFlag, "", "file the inventory is written to, defaults to stdout")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: leanix-k8s-connector %s [flags]\n\nWrites an inventory of the namespaces and workloads of a cluster without Iris.\n\n", utils.ExportCommand)
+		flags.PrintDefaults()
+	}
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+	if *configPath == "" {
+		fmt.Fprintf(os.Stderr, "%s flag must be set\n", utils.ExportConfigFlag)
+		return 2
+	}
+	if *format != export.FormatJSON && *format != export.FormatJSONLines && *format != export.FormatCSV && *format != export.FormatCycloneDX {
+		fmt.Fprintf(os.Stderr, "%s flag must be one of '%s', '%s', '%s' or '%s'\n", utils.ExportFormatFlag, export.FormatJSON, export.FormatJSONLines, export.FormatCSV, export.FormatCycloneDX)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = exportInventory(ctx, *kubeconfig, *kubeContext, *configPath, *format, *output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to export the inventory: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func exportInventory(ctx context.Context, kubeconfig string, kubeContext string, configPath string, format string, output string) error {
+	config, err := export.ReadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	if format == export.FormatCycloneDX && !config.DiscoveryMode.Workloads() {
+		return fmt.Errorf("the %s format needs the %s discovery mode", export.FormatCycloneDX, models.DiscoveryModeWorkload)
+	}
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: kubeContext}).ClientConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load the kubeconfig: %w", err)
+	}
+	kubernetesAPI, err := kubernetes.NewAPI(restConfig)
+	if err != nil {
+		return err
+	}
+	discovery, err := iris.Discover(ctx, kubernetesAPI, config)
+	if err != nil {
+		return err
+	}
+	inventory := export.NewInventory(config, discovery, time.Now().UTC())
+
+	if output == "" {
+		return export.Write(os.Stdout, format, inventory)
+	}
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	err = export.Write(file, format, inventory)
+	return errors.Join(err, file.Close())
+}
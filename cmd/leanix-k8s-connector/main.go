@@ -1,12 +1,22 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/services"
 	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
 	"github.com/leanix/leanix-k8s-connector/pkg/logger"
+	"github.com/leanix/leanix-k8s-connector/pkg/metrics"
+	"github.com/leanix/leanix-k8s-connector/pkg/sink"
+	"github.com/leanix/leanix-k8s-connector/pkg/tracing"
+	"net/http"
+	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/leanix/leanix-k8s-connector/pkg/iris"
 	"github.com/leanix/leanix-k8s-connector/pkg/leanix"
@@ -20,10 +30,16 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == utils.ExportCommand {
+		os.Exit(runExport(os.Args[2:]))
+	}
 	logger.Init()
 	err := parseFlags()
 	if err != nil {
 		logger.Error("Error occurred when parsing flags.", err)
+		// a misconfigured connector must not scan with what it guesses from the flags
+		_ = logger.Sync()
+		os.Exit(2)
 	}
 
 	var config *restclient.Config
@@ -46,7 +62,10 @@ func main() {
 	if apiToken == "" {
 		apiToken = viper.GetString(utils.ApiTokenFlag)
 	}
-	accessToken, err := leanix.Authenticate(apiHostFqdn, apiToken)
+	logger.Infof("LeanIX integration api fqdn : %s", apiHostFqdn)
+	tokenSource := leanix.NewTokenSource(http.DefaultClient, apiHostFqdn, apiToken)
+	// authenticate right away, so a wrong api token fails before anything is scanned
+	_, err = tokenSource.Token()
 	if err != nil {
 		logger.Error("Error occurred when authenticating.", err)
 		logger.Info("Failed to authenticate. Terminating..")
@@ -54,30 +73,247 @@ func main() {
 	}
 	if viper.GetBool(utils.IrisFlag) {
 		logger.Info("Enabled Iris")
-		runId := models.GenerateRunId()
-		irisScanner := iris.NewScanner(
-			"Iris Integration",
-			apiHostFqdn,
-			runId,
-			accessToken,
-			viper.GetString(utils.LxWorkspaceFlag),
-		)
+		dryRun, closeDryRun, err := dryRunConfig()
+		if err != nil {
+			logger.Error("Failed to prepare the dry run.", err)
+			return
+		}
+		defer closeDryRun()
+		runSink, err := newSink()
+		if err != nil {
+			logger.Error("Failed to prepare the storage backend.", err)
+			return
+		}
+		// every configuration is scanned with a run id of its own
+		newScanner := func(clusterName string) iris.Scanner {
+			return iris.NewScanner(
+				"Iris Integration",
+				apiHostFqdn,
+				models.GenerateRunId(),
+				tokenSource,
+				viper.GetString(utils.LxWorkspaceFlag),
+				clusterName,
+				services.BatchConfig{
+					MaxEvents:   viper.GetInt(utils.BatchMaxEventsFlag),
+					MaxBytes:    viper.GetInt(utils.BatchMaxBytesFlag),
+					Concurrency: viper.GetInt(utils.BatchConcurrencyFlag),
+				},
+				services.DeletionGuard{
+					MaxDeletions:       viper.GetInt(utils.MaxDeletionsFlag),
+					MaxDeletionPercent: viper.GetFloat64(utils.MaxDeletionPercentFlag),
+					MinKnownItems:      viper.GetInt(utils.MaxDeletionPercentMinKnownFlag),
+					AllowMassDeletion:  viper.GetBool(utils.AllowMassDeletionFlag),
+				},
+				services.RetryPolicy{
+					MaxAttempts:    viper.GetInt(utils.RetryMaxAttemptsFlag),
+					InitialBackoff: viper.GetDuration(utils.RetryInitialBackoffFlag),
+					MaxBackoff:     viper.GetDuration(utils.RetryMaxBackoffFlag),
+				},
+				dryRun,
+				runSink,
+			)
+		}
 
-		err = irisScanner.Scan(kubernetes.NewAPI, config, viper.GetString(utils.ConfigurationNameFlag))
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if address := viper.GetString(utils.MetricsAddressFlag); address != "" {
+			go func() {
+				err := metrics.Serve(ctx, address)
+				if err != nil {
+					logger.Error("Failed to serve metrics.", err)
+				}
+			}()
+		}
+		defer pushMetrics()
+		shutdownTracing, err := tracing.Setup(ctx, viper.GetString(utils.TracingExporterFlag), viper.GetString(utils.TracingEndpointFlag), viper.GetString(utils.TracingFileFlag))
 		if err != nil {
-			logger.Error("Failed to scan Kubernetes via vsm-iris.", err)
+			logger.Error("Failed to set up tracing.", err)
+			return
+		}
+		defer func() {
+			// the spans are flushed even though ctx may be cancelled by now
+			err := shutdownTracing(context.WithoutCancel(ctx))
+			if err != nil {
+				logger.Error("Failed to flush the traces.", err)
+			}
+		}()
+		run := func(ctx context.Context) {
+			if viper.GetString(utils.KubeconfigFlag) != "" {
+				runClusters(ctx, newScanner)
+				return
+			}
+			newConfigurationScanner := func() iris.Scanner {
+				return newScanner("")
+			}
+			if viper.GetString(utils.ModeFlag) == utils.WatchMode {
+				err := iris.WatchConfigurations(ctx, newConfigurationScanner, newKubernetesAPI, config, configurationNames(), viper.GetDuration(utils.ResyncPeriodFlag))
+				if err != nil {
+					logger.Error("Failed to watch Kubernetes via vsm-iris.", err)
+				}
+				return
+			}
+			err := iris.ScanConfigurations(ctx, newConfigurationScanner, newKubernetesAPI, config, configurationNames())
+			if err != nil {
+				logger.Error("Failed to scan Kubernetes via vsm-iris.", err)
+			}
+		}
+
+		if !viper.GetBool(utils.LeaderElectFlag) {
+			run(ctx)
+			return
+		}
+		kubernetesAPI, err := newKubernetesAPI(config)
+		if err != nil {
+			logger.Error("Failed to create Kubernetes API for leader election.", err)
+			return
+		}
+		err = kubernetesAPI.RunWithLeaderElection(ctx, leaderElectionConfig(), run)
+		if err != nil {
+			logger.Error("Leader election ended.", err)
 		}
 	} else {
 		logger.Error("Using deprecated configuration. Please set the iris flag to true.", err)
 	}
 }
 
+// pushMetrics pushes the metrics of the run to the Pushgateway, if one is configured
+func pushMetrics() {
+	url := viper.GetString(utils.MetricsPushgatewayFlag)
+	if url == "" {
+		return
+	}
+	err := metrics.Push(url, viper.GetString(utils.MetricsJobFlag))
+	if err != nil {
+		logger.Error("Failed to push metrics to the Pushgateway.", err)
+	}
+}
+
+// runClusters scans or watches the clusters of the contexts mapped in the clusters file
+func runClusters(ctx context.Context, newScanner func(clusterName string) iris.Scanner) {
+	mappings, err := kubernetes.ReadClusterMappings(viper.GetString(utils.ClustersFileFlag))
+	if err != nil {
+		logger.Error("Failed to read the cluster mappings.", err)
+		return
+	}
+	clusters, err := kubernetes.LoadClusters(viper.GetString(utils.KubeconfigFlag), mappings)
+	if err != nil {
+		logger.Error("Failed to load the clusters from the kubeconfig.", err)
+		return
+	}
+	if viper.GetString(utils.ModeFlag) == utils.WatchMode {
+		err = iris.WatchClusters(ctx, newScanner, newKubernetesAPI, clusters, viper.GetDuration(utils.ResyncPeriodFlag))
+		if err != nil {
+			logger.Error("Failed to watch the clusters via vsm-iris.", err)
+		}
+		return
+	}
+	err = iris.ScanClusters(ctx, newScanner, newKubernetesAPI, clusters, viper.GetInt(utils.ClusterParallelismFlag))
+	if err != nil {
+		logger.Error("Failed to scan the clusters via vsm-iris.", err)
+	}
+}
+
+// configurationNames returns the configurations of the flag, which is given as list of flags or
+// as comma separated list. Names may contain spaces, so the environment variable is only split on
+// commas, unlike viper.GetStringSlice which splits strings on whitespace.
+func configurationNames() []string {
+	var values []string
+	switch value := viper.Get(utils.ConfigurationNameFlag).(type) {
+	case string:
+		values = []string{value}
+	case []string:
+		values = value
+	default:
+		values = viper.GetStringSlice(utils.ConfigurationNameFlag)
+	}
+	names := make([]string, 0)
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// newKubernetesAPI creates the Kubernetes API with the page size and request timeout of the flags
+func newKubernetesAPI(config *restclient.Config) (*kubernetes.API, error) {
+	kubernetesAPI, err := kubernetes.NewAPI(config)
+	if err != nil {
+		return nil, err
+	}
+	kubernetesAPI.PageSize = viper.GetInt64(utils.KubernetesPageSizeFlag)
+	kubernetesAPI.RequestTimeout = viper.GetDuration(utils.KubernetesRequestTimeoutFlag)
+	return kubernetesAPI, nil
+}
+
+// dryRunConfig opens the file for the would-be payloads of a dry run. The returned func closes it.
+func dryRunConfig() (services.DryRunConfig, func(), error) {
+	dryRun := services.DryRunConfig{
+		Enabled: viper.GetBool(utils.DryRunFlag),
+		Output:  os.Stdout,
+	}
+	path := viper.GetString(utils.DryRunOutputFlag)
+	if !dryRun.Enabled || path == "" {
+		return dryRun, func() {}, nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return dryRun, nil, err
+	}
+	dryRun.Payloads = file
+	return dryRun, func() {
+		if err := file.Close(); err != nil {
+			logger.Error("Failed to close the dry run output.", err)
+		}
+	}, nil
+}
+
+// newSink creates the sink of the storage backend, nil if custom storage is disabled
+func newSink() (sink.Sink, error) {
+	if !viper.GetBool(utils.EnableCustomStorageFlag) {
+		return nil, nil
+	}
+	switch viper.GetString(utils.StorageBackendFlag) {
+	case sink.BackendFile:
+		return sink.NewFileSink(viper.GetString(utils.LocalFilePathFlag), viper.GetInt(utils.StorageRetentionFlag)), nil
+	case sink.BackendAzureBlob:
+		return sink.NewAzureBlobSink(
+			viper.GetString(utils.AzureAccountNameFlag),
+			viper.GetString(utils.AzureAccountKeyFlag),
+			viper.GetString(utils.AzureContainerFlag),
+			viper.GetString(utils.AzureEndpointFlag),
+		)
+	default:
+		return nil, fmt.Errorf("unknown storage backend '%s'", viper.GetString(utils.StorageBackendFlag))
+	}
+}
+
+func leaderElectionConfig() kubernetes.LeaderElectionConfig {
+	identity := viper.GetString(utils.LeaderElectionIdentityFlag)
+	if identity == "" {
+		identity, _ = os.Hostname()
+	}
+	return kubernetes.LeaderElectionConfig{
+		LeaseName:      viper.GetString(utils.LeaderElectionLeaseNameFlag),
+		LeaseNamespace: viper.GetString(utils.LeaderElectionNamespaceFlag),
+		Identity:       identity,
+		LeaseDuration:  viper.GetDuration(utils.LeaderElectionLeaseDurationFlag),
+		RenewDeadline:  viper.GetDuration(utils.LeaderElectionRenewDeadlineFlag),
+		RetryPeriod:    viper.GetDuration(utils.LeaderElectionRetryPeriodFlag),
+	}
+}
+
 func parseFlags() error {
 	flag.Bool(utils.EnableCustomStorageFlag, false, "Disable/enable custom storage backend option")
+	flag.String(utils.StorageBackendFlag, sink.BackendNone, "storage backend a copy of every run is kept in, 'file' or 'azureblob'")
 	flag.String(utils.AzureAccountNameFlag, "", "Azure storage account name")
 	flag.String(utils.AzureAccountKeyFlag, "", "Azure storage account key")
 	flag.String(utils.AzureContainerFlag, "", "Azure storage account container")
-	flag.String(utils.LocalFilePathFlag, ".", "path to place the ldif file when using local file storage backend")
+	flag.String(utils.AzureEndpointFlag, "", "Azure blob service endpoint, e.g. of the Azurite emulator, defaults to the endpoint of the storage account")
+	flag.String(utils.LocalFilePathFlag, ".", "directory the runs are written to when using local file storage backend")
+	flag.Int(utils.StorageRetentionFlag, 0, "number of runs per configuration kept by the local file storage backend, 0 keeps all")
 	flag.Bool(utils.VerboseFlag, false, "verbose log output")
 	flag.String(utils.IntegrationAPIDatasourceNameFlag, "", "LeanIX Integration Hub Datasource name created on the workspace")
 	flag.String(utils.IntegrationAPIFqdnFlag, "", "LeanIX Instance FQDN - deprecated flag")
@@ -88,7 +324,39 @@ func parseFlags() error {
 	flag.String(utils.LxWorkspaceFlag, "", "name of the LeanIX workspace the data is sent to")
 	flag.Bool(utils.LocalFlag, false, "use local kubeconfig from home folder")
 	flag.Bool(utils.IrisFlag, false, "send kubernetes events to new integration api")
-	flag.String(utils.ConfigurationNameFlag, "", "Leanix configuration name created on the workspace")
+	flag.StringSlice(utils.ConfigurationNameFlag, []string{}, "comma separated list of Leanix configuration names created on the workspace, each is scanned with its own run id")
+	flag.String(utils.KubeconfigFlag, "", "kubeconfig file, or directory of kubeconfig files, with the contexts of the clusters to scan instead of the own cluster")
+	flag.String(utils.ClustersFileFlag, "", "YAML file mapping the contexts of the kubeconfig to a cluster name and the configurations they are scanned with, clusters sharing a configuration need distinct cluster names")
+	flag.Int(utils.ClusterParallelismFlag, 4, "number of clusters scanned at the same time")
+	flag.String(utils.ModeFlag, utils.ScanMode, "'scan' runs a single scan and exits, 'watch' keeps running and posts changes as they happen")
+	flag.Duration(utils.ResyncPeriodFlag, time.Hour, "interval of the full resync with Iris in watch mode")
+	flag.Bool(utils.LeaderElectFlag, false, "only scan on the replica holding the leader election lease")
+	flag.String(utils.LeaderElectionLeaseNameFlag, "leanix-k8s-connector", "name of the lease used for leader election")
+	flag.String(utils.LeaderElectionNamespaceFlag, "", "namespace of the lease used for leader election")
+	flag.String(utils.LeaderElectionIdentityFlag, "", "identity of this replica in the leader election, defaults to the hostname")
+	flag.Duration(utils.LeaderElectionLeaseDurationFlag, 15*time.Second, "duration non-leader replicas wait before trying to acquire the lease")
+	flag.Duration(utils.LeaderElectionRenewDeadlineFlag, 10*time.Second, "duration the leader retries renewing the lease before giving up leadership")
+	flag.Duration(utils.LeaderElectionRetryPeriodFlag, 2*time.Second, "interval between leader election attempts")
+	flag.Int64(utils.KubernetesPageSizeFlag, kubernetes.DefaultPageSize, "number of items requested per Kubernetes list call, 0 disables paging")
+	flag.Duration(utils.KubernetesRequestTimeoutFlag, kubernetes.DefaultRequestTimeout, "timeout of a single Kubernetes list request, 0 disables the timeout")
+	flag.Int(utils.BatchMaxEventsFlag, services.DefaultBatchConfig.MaxEvents, "maximum number of ECST events posted to Iris in one request, 0 disables the limit")
+	flag.Int(utils.BatchMaxBytesFlag, services.DefaultBatchConfig.MaxBytes, "maximum size in bytes of a request posting ECST events to Iris, 0 disables the limit")
+	flag.Int(utils.BatchConcurrencyFlag, services.DefaultBatchConfig.Concurrency, "number of ECST event batches posted to Iris at the same time")
+	flag.Int(utils.RetryMaxAttemptsFlag, services.DefaultRetryPolicy.MaxAttempts, "number of attempts of a failed request to Iris, 1 disables retries")
+	flag.Duration(utils.RetryInitialBackoffFlag, services.DefaultRetryPolicy.InitialBackoff, "delay before the first retry of a failed request to Iris, doubled with every retry")
+	flag.Duration(utils.RetryMaxBackoffFlag, services.DefaultRetryPolicy.MaxBackoff, "maximum delay between two attempts of a request to Iris, also caps Retry-After")
+	flag.Bool(utils.DryRunFlag, false, "read configuration and results from Iris and print the changes instead of posting them")
+	flag.String(utils.DryRunOutputFlag, "", "file the would-be ECST payloads of a dry run are written to, one request body per line")
+	flag.Int(utils.MaxDeletionsFlag, services.DefaultDeletionGuard.MaxDeletions, "maximum number of items deleted in one run before the run is failed instead, 0 disables the limit")
+	flag.Float64(utils.MaxDeletionPercentFlag, services.DefaultDeletionGuard.MaxDeletionPercent, "maximum percentage of the previously known items deleted in one run before the run is failed instead, 0 disables the limit")
+	flag.Int(utils.MaxDeletionPercentMinKnownFlag, services.DefaultDeletionGuard.MinKnownItems, "number of previously known items below which the deletion percentage is not limited")
+	flag.Bool(utils.AllowMassDeletionFlag, false, "post the deleted items regardless of the deletion limits, for an intentional cleanup")
+	flag.String(utils.MetricsAddressFlag, "", "address the Prometheus metrics are served on at /metrics, e.g. ':9090', empty disables the endpoint")
+	flag.String(utils.MetricsPushgatewayFlag, "", "URL of a Prometheus Pushgateway the metrics are pushed to when the connector exits")
+	flag.String(utils.MetricsJobFlag, "leanix-k8s-connector", "job name the metrics are pushed to the Pushgateway under")
+	flag.String(utils.TracingExporterFlag, tracing.ExporterNone, "exporter of the OpenTelemetry traces, 'otlp' or 'file', empty disables tracing")
+	flag.String(utils.TracingEndpointFlag, "", "URL of the OTLP/HTTP collector the traces are sent to, e.g. 'http://localhost:4318', defaults to the OTEL_EXPORTER_OTLP_* environment variables")
+	flag.String(utils.TracingFileFlag, "", "file the traces are written to as JSON with the 'file' exporter")
 	flag.Parse()
 	// Let flags overwrite configs in viper
 	err := viper.BindPFlags(flag.CommandLine)
@@ -108,7 +376,12 @@ func parseFlags() error {
 			return fmt.Errorf("%s flag must be set since %s is enabled", utils.StorageBackendFlag, utils.EnableCustomStorageFlag)
 		}
 
-		if viper.GetString(utils.StorageBackendFlag) == "azureblob" {
+		switch viper.GetString(utils.StorageBackendFlag) {
+		case sink.BackendFile:
+			if viper.GetInt(utils.StorageRetentionFlag) < 0 {
+				return fmt.Errorf("%s flag must not be negative", utils.StorageRetentionFlag)
+			}
+		case sink.BackendAzureBlob:
 			if viper.GetString(utils.AzureAccountNameFlag) == "" {
 				return fmt.Errorf("%s flag must be set", utils.AzureAccountNameFlag)
 			}
@@ -118,12 +391,79 @@ func parseFlags() error {
 			if viper.GetString(utils.AzureContainerFlag) == "" {
 				return fmt.Errorf("%s flag must be set", utils.AzureContainerFlag)
 			}
+		default:
+			return fmt.Errorf("%s flag must be one of '%s' or '%s'", utils.StorageBackendFlag, sink.BackendFile, sink.BackendAzureBlob)
 		}
 	}
-	if viper.GetBool(utils.IrisFlag) {
-		if viper.GetString(utils.ConfigurationNameFlag) == "" {
-			return fmt.Errorf("%s flag must be set", utils.ConfigurationNameFlag)
+	if viper.GetString(utils.KubeconfigFlag) != "" {
+		if viper.GetString(utils.ClustersFileFlag) == "" {
+			return fmt.Errorf("%s flag must be set since %s is set", utils.ClustersFileFlag, utils.KubeconfigFlag)
 		}
+		if viper.GetInt(utils.ClusterParallelismFlag) < 1 {
+			return fmt.Errorf("%s flag must be at least 1", utils.ClusterParallelismFlag)
+		}
+	} else if viper.GetBool(utils.IrisFlag) && len(configurationNames()) == 0 {
+		return fmt.Errorf("%s flag must be set", utils.ConfigurationNameFlag)
+	}
+	if mode := viper.GetString(utils.ModeFlag); mode != utils.ScanMode && mode != utils.WatchMode {
+		return fmt.Errorf("%s flag must be one of '%s' or '%s'", utils.ModeFlag, utils.ScanMode, utils.WatchMode)
+	}
+	if viper.GetString(utils.ModeFlag) == utils.WatchMode && viper.GetDuration(utils.ResyncPeriodFlag) <= 0 {
+		return fmt.Errorf("%s flag must be a positive duration", utils.ResyncPeriodFlag)
+	}
+	if viper.GetInt64(utils.KubernetesPageSizeFlag) < 0 {
+		return fmt.Errorf("%s flag must not be negative", utils.KubernetesPageSizeFlag)
+	}
+	if viper.GetDuration(utils.KubernetesRequestTimeoutFlag) < 0 {
+		return fmt.Errorf("%s flag must not be negative", utils.KubernetesRequestTimeoutFlag)
+	}
+	for _, batchFlag := range []string{utils.BatchMaxEventsFlag, utils.BatchMaxBytesFlag} {
+		if viper.GetInt(batchFlag) < 0 {
+			return fmt.Errorf("%s flag must not be negative", batchFlag)
+		}
+	}
+	if viper.GetInt(utils.BatchConcurrencyFlag) < 1 {
+		return fmt.Errorf("%s flag must be at least 1", utils.BatchConcurrencyFlag)
+	}
+	if viper.GetInt(utils.RetryMaxAttemptsFlag) < 1 {
+		return fmt.Errorf("%s flag must be at least 1", utils.RetryMaxAttemptsFlag)
+	}
+	if viper.GetDuration(utils.RetryInitialBackoffFlag) < 0 || viper.GetDuration(utils.RetryMaxBackoffFlag) < viper.GetDuration(utils.RetryInitialBackoffFlag) {
+		return fmt.Errorf("%s flag must not be negative and not exceed %s", utils.RetryInitialBackoffFlag, utils.RetryMaxBackoffFlag)
+	}
+	if viper.GetInt(utils.MaxDeletionsFlag) < 0 {
+		return fmt.Errorf("%s flag must not be negative", utils.MaxDeletionsFlag)
+	}
+	if percent := viper.GetFloat64(utils.MaxDeletionPercentFlag); percent < 0 || percent > 100 {
+		return fmt.Errorf("%s flag must be between 0 and 100", utils.MaxDeletionPercentFlag)
+	}
+	if viper.GetInt(utils.MaxDeletionPercentMinKnownFlag) < 0 {
+		return fmt.Errorf("%s flag must not be negative", utils.MaxDeletionPercentMinKnownFlag)
+	}
+	if viper.GetString(utils.DryRunOutputFlag) != "" && !viper.GetBool(utils.DryRunFlag) {
+		return fmt.Errorf("%s flag requires %s", utils.DryRunOutputFlag, utils.DryRunFlag)
+	}
+	if viper.GetString(utils.MetricsPushgatewayFlag) != "" && viper.GetString(utils.MetricsJobFlag) == "" {
+		return fmt.Errorf("%s flag must be set since %s is set", utils.MetricsJobFlag, utils.MetricsPushgatewayFlag)
+	}
+	switch viper.GetString(utils.TracingExporterFlag) {
+	case tracing.ExporterNone, tracing.ExporterOTLP:
+	case tracing.ExporterFile:
+		if viper.GetString(utils.TracingFileFlag) == "" {
+			return fmt.Errorf("%s flag must be set since %s is '%s'", utils.TracingFileFlag, utils.TracingExporterFlag, tracing.ExporterFile)
+		}
+	default:
+		return fmt.Errorf("%s flag must be one of '%s' or '%s'", utils.TracingExporterFlag, tracing.ExporterOTLP, tracing.ExporterFile)
+	}
+	if viper.GetBool(utils.LeaderElectFlag) {
+		if viper.GetString(utils.LeaderElectionNamespaceFlag) == "" {
+			return fmt.Errorf("%s flag must be set since %s is enabled", utils.LeaderElectionNamespaceFlag, utils.LeaderElectFlag)
+		}
+		if viper.GetDuration(utils.LeaderElectionRenewDeadlineFlag) >= viper.GetDuration(utils.LeaderElectionLeaseDurationFlag) {
+			return fmt.Errorf("%s flag must be shorter than %s", utils.LeaderElectionRenewDeadlineFlag, utils.LeaderElectionLeaseDurationFlag)
+		}
+	}
+	if viper.GetBool(utils.IrisFlag) {
 		return nil
 	}
 	if viper.GetString(utils.IntegrationAPIDatasourceNameFlag) == "" {
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/utils"
+	flag "github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// bindConfigurationNames binds the configuration name flag and environment variable like parseFlags
+func bindConfigurationNames(t *testing.T, args ...string) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.StringSlice(utils.ConfigurationNameFlag, []string{}, "")
+	assert.NoError(t, flags.Parse(args))
+	assert.NoError(t, viper.BindPFlags(flags))
+	viper.AutomaticEnv()
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+}
+
+func TestConfigurationNames_environment(t *testing.T) {
+	t.Setenv("CONFIGURATION_NAME", "Prod Cluster, Staging Cluster,")
+	bindConfigurationNames(t)
+
+	assert.Equal(t, []string{"Prod Cluster", "Staging Cluster"}, configurationNames())
+}
+
+func TestConfigurationNames_flags(t *testing.T) {
+	bindConfigurationNames(t, "--configuration-name", "Prod Cluster", "--configuration-name", "team-a,team-b")
+
+	assert.Equal(t, []string{"Prod Cluster", "team-a", "team-b"}, configurationNames())
+}
+
+func TestConfigurationNames_unset(t *testing.T) {
+	bindConfigurationNames(t)
+
+	assert.Empty(t, configurationNames())
+}
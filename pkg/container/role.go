@@ -0,0 +1,9 @@
+package container
+
+// Roles a container plays in a pod
+const (
+	RoleMain      string = "main"
+	RoleInit      string = "init"
+	RoleSidecar   string = "sidecar"
+	RoleEphemeral string = "ephemeral"
+)
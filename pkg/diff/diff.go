@@ -0,0 +1,123 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+const (
+	KindAdded   string = "added"
+	KindRemoved string = "removed"
+	KindChanged string = "changed"
+)
+
+// Change is a difference between two values at a path like workload.labels.app or
+// workload.workloadProperties.containers[0].image
+type Change struct {
+	Path string      `json:"path"`
+	Kind string      `json:"kind"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// Compare returns the differences between the JSON representations of old and new sorted by path.
+// Objects are compared key by key and arrays index by index, other values as a whole.
+func Compare(old interface{}, new interface{}) ([]Change, error) {
+	oldValue, err := normalize(old)
+	if err != nil {
+		return nil, err
+	}
+	newValue, err := normalize(new)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]Change, 0)
+	compare("", oldValue, newValue, &changes)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+// String formats the change as a single line, e.g. replicas: "1" -> "2"
+func (c Change) String() string {
+	switch c.Kind {
+	case KindAdded:
+		return fmt.Sprintf("%s: added %s", c.Path, format(c.New))
+	case KindRemoved:
+		return fmt.Sprintf("%s: removed %s", c.Path, format(c.Old))
+	}
+	return fmt.Sprintf("%s: %s -> %s", c.Path, format(c.Old), format(c.New))
+}
+
+// normalize turns structs into the maps, slices and scalars encoding/json decodes them to
+func normalize(value interface{}) (interface{}, error) {
+	marshalled, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	err = json.Unmarshal(marshalled, &normalized)
+	return normalized, err
+}
+
+func compare(path string, old interface{}, new interface{}, changes *[]Change) {
+	switch oldValue := old.(type) {
+	case map[string]interface{}:
+		if newValue, ok := new.(map[string]interface{}); ok {
+			for key, value := range oldValue {
+				if newField, ok := newValue[key]; ok {
+					compare(join(path, key), value, newField, changes)
+				} else {
+					*changes = append(*changes, Change{Path: join(path, key), Kind: KindRemoved, Old: value})
+				}
+			}
+			for key, value := range newValue {
+				if _, ok := oldValue[key]; !ok {
+					*changes = append(*changes, Change{Path: join(path, key), Kind: KindAdded, New: value})
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if newValue, ok := new.([]interface{}); ok {
+			for i := 0; i < len(oldValue) || i < len(newValue); i++ {
+				itemPath := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case i >= len(newValue):
+					*changes = append(*changes, Change{Path: itemPath, Kind: KindRemoved, Old: oldValue[i]})
+				case i >= len(oldValue):
+					*changes = append(*changes, Change{Path: itemPath, Kind: KindAdded, New: newValue[i]})
+				default:
+					compare(itemPath, oldValue[i], newValue[i], changes)
+				}
+			}
+			return
+		}
+	}
+	if !equal(old, new) {
+		*changes = append(*changes, Change{Path: path, Kind: KindChanged, Old: old, New: new})
+	}
+}
+
+func equal(old interface{}, new interface{}) bool {
+	oldJson, oldErr := json.Marshal(old)
+	newJson, newErr := json.Marshal(new)
+	return oldErr == nil && newErr == nil && string(oldJson) == string(newJson)
+}
+
+func join(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func format(value interface{}) string {
+	formatted, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(formatted)
+}
@@ -0,0 +1,65 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type container struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+type item struct {
+	Replicas   string            `json:"replicas"`
+	Labels     map[string]string `json:"labels"`
+	Containers []container       `json:"containers"`
+}
+
+func TestCompare(t *testing.T) {
+	old := item{
+		Replicas:   "1",
+		Labels:     map[string]string{"app": "shop", "team": "a"},
+		Containers: []container{{Name: "app", Image: "app:1.0"}, {Name: "proxy", Image: "envoy:1.29"}},
+	}
+	new := item{
+		Replicas:   "2",
+		Labels:     map[string]string{"app": "shop", "tier": "web"},
+		Containers: []container{{Name: "app", Image: "app:1.1"}},
+	}
+
+	changes, err := Compare(old, new)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Path: "containers[0].image", Kind: KindChanged, Old: "app:1.0", New: "app:1.1"},
+		{Path: "containers[1]", Kind: KindRemoved, Old: map[string]interface{}{"name": "proxy", "image": "envoy:1.29"}},
+		{Path: "labels.team", Kind: KindRemoved, Old: "a"},
+		{Path: "labels.tier", Kind: KindAdded, New: "web"},
+		{Path: "replicas", Kind: KindChanged, Old: "1", New: "2"},
+	}, changes)
+}
+
+func TestCompare_equal(t *testing.T) {
+	// a struct and the map decoded from its JSON are equal
+	old := map[string]interface{}{"replicas": "1", "labels": nil, "containers": nil}
+
+	changes, err := Compare(old, item{Replicas: "1"})
+
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestCompare_typeChanged(t *testing.T) {
+	changes, err := Compare(map[string]interface{}{"port": []int{80}}, map[string]interface{}{"port": 80})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{{Path: "port", Kind: KindChanged, Old: []interface{}{float64(80)}, New: float64(80)}}, changes)
+}
+
+func TestChangeString(t *testing.T) {
+	assert.Equal(t, `replicas: "1" -> "2"`, Change{Path: "replicas", Kind: KindChanged, Old: "1", New: "2"}.String())
+	assert.Equal(t, `labels.tier: added "web"`, Change{Path: "labels.tier", Kind: KindAdded, New: "web"}.String())
+	assert.Equal(t, `labels.team: removed "a"`, Change{Path: "labels.team", Kind: KindRemoved, Old: "a"}.String())
+}
@@ -0,0 +1,229 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+)
+
+const cycloneDXSpecVersion = "1.5"
+
+// bom is a CycloneDX bill of materials with the cluster as root component, its workloads as
+// application components and the images of their containers as container components
+type bom struct {
+	BOMFormat    string       `json:"bomFormat"`
+	SpecVersion  string       `json:"specVersion"`
+	SerialNumber string       `json:"serialNumber"`
+	Version      int          `json:"version"`
+	Metadata     bomMetadata  `json:"metadata"`
+	Components   []component  `json:"components"`
+	Dependencies []dependency `json:"dependencies"`
+}
+
+type bomMetadata struct {
+	Timestamp string    `json:"timestamp"`
+	Tools     bomTools  `json:"tools"`
+	Component component `json:"component"`
+}
+
+type bomTools struct {
+	Components []component `json:"components"`
+}
+
+type component struct {
+	Type       string     `json:"type"`
+	BOMRef     string     `json:"bom-ref,omitempty"`
+	Group      string     `json:"group,omitempty"`
+	Name       string     `json:"name"`
+	Version    string     `json:"version,omitempty"`
+	Purl       string     `json:"purl,omitempty"`
+	Hashes     []hash     `json:"hashes,omitempty"`
+	Properties []property `json:"properties,omitempty"`
+}
+
+type hash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type property struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type dependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// hashAlgorithms maps the algorithms of image digests to the CycloneDX hash algorithms
+var hashAlgorithms = map[string]string{
+	"sha256": "SHA-256",
+	"sha384": "SHA-384",
+	"sha512": "SHA-512",
+}
+
+func writeCycloneDX(w io.Writer, inventory Inventory) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(newBOM(inventory, uuid.New()))
+}
+
+func newBOM(inventory Inventory, serialNumber uuid.UUID) bom {
+	cluster := clusterComponent(inventory)
+	components := make([]component, 0)
+	dependencies := make([]dependency, 0)
+	workloadRefs := make([]string, 0)
+	images := map[string]bool{}
+	for _, item := range inventory.Items {
+		data, ok := item.Data.(workload.Data)
+		if !ok {
+			continue
+		}
+		workloadComponent := component{
+			Type:   "application",
+			BOMRef: fmt.Sprintf("workload/%s/%s/%s", data.NamespaceName, data.Workload.WorkloadType, data.Workload.Name),
+			Group:  data.NamespaceName,
+			Name:   data.Workload.Name,
+			Properties: []property{
+				{Name: "leanix:kubernetes:namespace", Value: data.NamespaceName},
+				{Name: "leanix:kubernetes:workloadType", Value: data.Workload.WorkloadType},
+				{Name: "leanix:kubernetes:replicas", Value: data.Workload.WorkloadProperties.Replicas},
+			},
+		}
+		components = append(components, workloadComponent)
+		workloadRefs = append(workloadRefs, workloadComponent.BOMRef)
+
+		imageRefs := make([]string, 0)
+		for _, container := range data.Workload.WorkloadProperties.Containers {
+			for _, image := range imageComponents(container) {
+				imageRefs = append(imageRefs, image.BOMRef)
+				// workloads running the same image share its component
+				if !images[image.BOMRef] {
+					images[image.BOMRef] = true
+					components = append(components, image)
+				}
+			}
+		}
+		dependencies = append(dependencies, dependency{Ref: workloadComponent.BOMRef, DependsOn: distinct(imageRefs)})
+	}
+	dependencies = append([]dependency{{Ref: cluster.BOMRef, DependsOn: workloadRefs}}, dependencies...)
+
+	return bom{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  cycloneDXSpecVersion,
+		SerialNumber: serialNumber.URN(),
+		Version:      1,
+		Metadata: bomMetadata{
+			Timestamp: inventory.ExportedAt.Format(time.RFC3339),
+			Tools:     bomTools{Components: []component{{Type: "application", Group: "leanix", Name: "leanix-k8s-connector"}}},
+			Component: cluster,
+		},
+		Components:   components,
+		Dependencies: dependencies,
+	}
+}
+
+// clusterComponent describes the cluster with the cluster information the workloads were mapped with
+func clusterComponent(inventory Inventory) component {
+	cluster := component{
+		Type:   "platform",
+		BOMRef: "cluster/" + inventory.Cluster,
+		Name:   inventory.Cluster,
+	}
+	for _, item := range inventory.Items {
+		if data, ok := item.Data.(workload.Data); ok {
+			cluster.Version = data.Cluster.K8sVersion
+			cluster.Properties = []property{
+				{Name: "leanix:kubernetes:os", Value: data.Cluster.OsImage},
+				{Name: "leanix:kubernetes:nodes", Value: fmt.Sprint(data.Cluster.NoOfNodes)},
+			}
+			break
+		}
+	}
+	return cluster
+}
+
+// imageComponents returns a component for the image of the container. Without a digest in the
+// image reference, there is a component for every digest the pods run instead, if they report one.
+func imageComponents(container workload.Container) []component {
+	if container.ImageRepository == "" {
+		// the image reference could not be parsed, so there is no purl either
+		return []component{{Type: "container", BOMRef: "image/" + container.Image, Name: container.Image}}
+	}
+	digests := make([]string, 0)
+	if container.ImageDigest != "" {
+		digests = append(digests, container.ImageDigest)
+	} else {
+		for _, running := range container.RunningImages {
+			if running.Digest != "" {
+				digests = append(digests, running.Digest)
+			}
+		}
+	}
+	if len(digests) == 0 {
+		digests = append(digests, "")
+	}
+
+	components := make([]component, 0, len(digests))
+	for _, digest := range distinct(digests) {
+		image := component{
+			Type:    "container",
+			Group:   container.ImageRegistry,
+			Name:    container.ImageRepository,
+			Version: container.ImageTag,
+			Purl:    imagePurl(container.ImageRegistry, container.ImageRepository, container.ImageTag, digest),
+		}
+		if image.Version == "" {
+			image.Version = digest
+		}
+		image.BOMRef = image.Purl
+		if algorithm, content, ok := strings.Cut(digest, ":"); ok && hashAlgorithms[algorithm] != "" {
+			image.Hashes = []hash{{Alg: hashAlgorithms[algorithm], Content: content}}
+		}
+		components = append(components, image)
+	}
+	return components
+}
+
+// imagePurl creates the package URL of an OCI image, e.g.
+// pkg:oci/nginx@sha256%3A0123?repository_url=docker.io/library/nginx&tag=1.25
+func imagePurl(registry string, repository string, tag string, digest string) string {
+	name := strings.ToLower(repository[strings.LastIndex(repository, "/")+1:])
+	purl := "pkg:oci/" + url.QueryEscape(name)
+	if digest != "" {
+		purl += "@" + url.QueryEscape(digest)
+	}
+	qualifiers := []string{"repository_url=" + escapeQualifier(strings.ToLower(registry+"/"+repository))}
+	if tag != "" {
+		qualifiers = append(qualifiers, "tag="+escapeQualifier(tag))
+	}
+	return purl + "?" + strings.Join(qualifiers, "&")
+}
+
+// escapeQualifier percent-encodes a qualifier value, slashes are kept like in the examples of the
+// purl specification
+func escapeQualifier(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "%2F", "/")
+}
+
+// distinct returns the values without duplicates, sorted
+func distinct(values []string) []string {
+	seen := map[string]bool{}
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
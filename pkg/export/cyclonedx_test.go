@@ -0,0 +1,112 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leanix/leanix-k8s-connector/pkg/iris"
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const testDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000001"
+
+func TestImagePurl(t *testing.T) {
+	tests := []struct {
+		registry, repository, tag, digest string
+		expected                          string
+	}{
+		{"docker.io", "library/nginx", "1.25", "", "pkg:oci/nginx?repository_url=docker.io/library/nginx&tag=1.25"},
+		{"docker.io", "library/nginx", "", testDigest, "pkg:oci/nginx@sha256%3A0000000000000000000000000000000000000000000000000000000000000001?repository_url=docker.io/library/nginx"},
+		{"ghcr.io", "Org/App", "v1", testDigest, "pkg:oci/app@sha256%3A0000000000000000000000000000000000000000000000000000000000000001?repository_url=ghcr.io/org/app&tag=v1"},
+		{"localhost:5000", "app", "latest", "", "pkg:oci/app?repository_url=localhost%3A5000/app&tag=latest"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, imagePurl(test.registry, test.repository, test.tag, test.digest))
+	}
+}
+
+func TestImageComponents(t *testing.T) {
+	running := workload.Container{
+		Image:           "shop:1.0",
+		ImageRegistry:   "docker.io",
+		ImageRepository: "library/shop",
+		ImageTag:        "1.0",
+		RunningImages: []workload.RunningImage{
+			{ImageID: "docker.io/library/shop@" + testDigest, Digest: testDigest},
+			{ImageID: "unknown"},
+		},
+	}
+	pinned := workload.Container{
+		Image:           "shop@" + testDigest,
+		ImageRegistry:   "docker.io",
+		ImageRepository: "library/shop",
+		ImageDigest:     testDigest,
+	}
+	unparsable := workload.Container{Image: "Not An Image"}
+
+	assert.Equal(t, []component{{
+		Type:    "container",
+		BOMRef:  "pkg:oci/shop@sha256%3A0000000000000000000000000000000000000000000000000000000000000001?repository_url=docker.io/library/shop&tag=1.0",
+		Group:   "docker.io",
+		Name:    "library/shop",
+		Version: "1.0",
+		Purl:    "pkg:oci/shop@sha256%3A0000000000000000000000000000000000000000000000000000000000000001?repository_url=docker.io/library/shop&tag=1.0",
+		Hashes:  []hash{{Alg: "SHA-256", Content: "0000000000000000000000000000000000000000000000000000000000000001"}},
+	}}, imageComponents(running))
+	pinnedComponents := imageComponents(pinned)
+	assert.Len(t, pinnedComponents, 1)
+	assert.Equal(t, testDigest, pinnedComponents[0].Version)
+	assert.Equal(t, []component{{Type: "container", BOMRef: "image/Not An Image", Name: "Not An Image"}}, imageComponents(unparsable))
+}
+
+func TestWriteCycloneDX(t *testing.T) {
+	shop := workload.Container{Image: "shop:1.0", ImageRegistry: "docker.io", ImageRepository: "library/shop", ImageTag: "1.0"}
+	discovery := iris.Discovery{Workloads: []workload.Data{
+		{
+			Workload:      workload.Workload{Name: "shop", WorkloadType: "deployment", WorkloadProperties: workload.WorkloadProperties{Replicas: "2", Containers: workload.Containers{shop}}},
+			NamespaceName: "web",
+			Cluster:       workload.Cluster{Name: "prod", K8sVersion: "v1.29.2", OsImage: "Ubuntu 22.04", NoOfNodes: 3},
+		},
+		{
+			Workload:      workload.Workload{Name: "shop-canary", WorkloadType: "deployment", WorkloadProperties: workload.WorkloadProperties{Containers: workload.Containers{shop}}},
+			NamespaceName: "web",
+			Cluster:       workload.Cluster{Name: "prod", K8sVersion: "v1.29.2", OsImage: "Ubuntu 22.04", NoOfNodes: 3},
+		},
+	}}
+	inventory := NewInventory(models.KubernetesConfig{Cluster: "prod"}, discovery, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	var out bytes.Buffer
+
+	err := Write(&out, FormatCycloneDX, inventory)
+
+	assert.NoError(t, err)
+	var written bom
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &written))
+	assert.Equal(t, "CycloneDX", written.BOMFormat)
+	assert.Equal(t, "1.5", written.SpecVersion)
+	_, err = uuid.Parse(written.SerialNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-05-01T12:00:00Z", written.Metadata.Timestamp)
+	assert.Equal(t, component{
+		Type:    "platform",
+		BOMRef:  "cluster/prod",
+		Name:    "prod",
+		Version: "v1.29.2",
+		Properties: []property{
+			{Name: "leanix:kubernetes:os", Value: "Ubuntu 22.04"},
+			{Name: "leanix:kubernetes:nodes", Value: "3"},
+		},
+	}, written.Metadata.Component)
+	// both workloads share the component of their image
+	assert.Len(t, written.Components, 3)
+	imageRef := "pkg:oci/shop?repository_url=docker.io/library/shop&tag=1.0"
+	assert.Equal(t, []dependency{
+		{Ref: "cluster/prod", DependsOn: []string{"workload/web/deployment/shop", "workload/web/deployment/shop-canary"}},
+		{Ref: "workload/web/deployment/shop", DependsOn: []string{imageRef}},
+		{Ref: "workload/web/deployment/shop-canary", DependsOn: []string{imageRef}},
+	}, written.Dependencies)
+}
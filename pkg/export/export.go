@@ -0,0 +1,198 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris"
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	"github.com/leanix/leanix-k8s-connector/pkg/set"
+	"sigs.k8s.io/yaml"
+)
+
+// Formats an inventory can be written in
+const (
+	FormatJSON      string = "json"
+	FormatJSONLines string = "jsonl"
+	FormatCSV       string = "csv"
+	FormatCycloneDX string = "cyclonedx"
+)
+
+// Kinds of the exported items
+const (
+	KindNamespace string = "namespace"
+	KindWorkload  string = "workload"
+)
+
+// Inventory is everything discovered in a cluster with a configuration
+type Inventory struct {
+	Cluster         string    `json:"cluster"`
+	ConfigurationId string    `json:"configurationId"`
+	ExportedAt      time.Time `json:"exportedAt"`
+	Items           []Item    `json:"items"`
+}
+
+// Item is an exported namespace or workload together with the data the mapper produced for it
+type Item struct {
+	Kind      string `json:"kind"`
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	// Type is the workload type, or namespace for namespaces
+	Type string      `json:"type"`
+	Name string      `json:"name"`
+	Data interface{} `json:"data"`
+	// columns are the values of the item in the CSV format
+	columns csvColumns
+}
+
+type csvColumns struct {
+	replicas string
+	images   []string
+	services []string
+	labels   map[string]string
+}
+
+var csvHeader = []string{"kind", "cluster", "namespace", "type", "name", "replicas", "images", "services", "labels"}
+
+// ReadConfig reads the configuration the cluster is exported with from a YAML or JSON file, in the
+// format of the Iris configurations
+func ReadConfig(path string) (models.KubernetesConfig, error) {
+	var config models.KubernetesConfig
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	err = yaml.UnmarshalStrict(content, &config)
+	if err != nil {
+		return config, fmt.Errorf("failed to parse configuration %s: %w", path, err)
+	}
+	if config.Cluster == "" {
+		return config, fmt.Errorf("configuration %s needs a cluster name", path)
+	}
+	return config, nil
+}
+
+// NewInventory lists the discovered namespaces followed by the discovered workloads
+func NewInventory(config models.KubernetesConfig, discovery iris.Discovery, exportedAt time.Time) Inventory {
+	items := make([]Item, 0, len(discovery.Namespaces)+len(discovery.Workloads))
+	for _, namespace := range discovery.Namespaces {
+		images, services := set.NewStringSet(), set.NewStringSet()
+		for _, deployment := range namespace.Cluster.Deployments {
+			for _, container := range deployment.Containers {
+				images.Add(container.Image)
+			}
+			if deployment.ServiceName != "" {
+				services.Add(deployment.ServiceName)
+			}
+		}
+		items = append(items, Item{
+			Kind:      KindNamespace,
+			Cluster:   namespace.Cluster.Name,
+			Namespace: namespace.Cluster.Namespace,
+			Type:      KindNamespace,
+			Name:      namespace.Cluster.Namespace,
+			Data:      namespace,
+			columns:   csvColumns{images: images.Items(), services: services.Items()},
+		})
+	}
+	for _, item := range discovery.Workloads {
+		images, services := set.NewStringSet(), set.NewStringSet()
+		for _, container := range item.Workload.WorkloadProperties.Containers {
+			images.Add(container.Image)
+		}
+		for _, service := range item.Services {
+			services.Add(service.Name)
+		}
+		if item.ServiceName != "" {
+			services.Add(item.ServiceName)
+		}
+		items = append(items, Item{
+			Kind:      KindWorkload,
+			Cluster:   item.Cluster.Name,
+			Namespace: item.NamespaceName,
+			Type:      item.Workload.WorkloadType,
+			Name:      item.Workload.Name,
+			Data:      item,
+			columns: csvColumns{
+				replicas: item.Workload.WorkloadProperties.Replicas,
+				images:   images.Items(),
+				services: services.Items(),
+				labels:   item.Workload.Labels,
+			},
+		})
+	}
+	return Inventory{
+		Cluster:         config.Cluster,
+		ConfigurationId: config.ID,
+		ExportedAt:      exportedAt,
+		Items:           items,
+	}
+}
+
+// Write writes the inventory in the format. JSON is a single document, JSON Lines an item per line
+// and CSV a row per item with the most common fields, lists are separated by semicolons. CycloneDX
+// is a JSON bill of materials of the workloads and their images.
+func Write(w io.Writer, format string, inventory Inventory) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(inventory)
+	case FormatJSONLines:
+		encoder := json.NewEncoder(w)
+		for _, item := range inventory.Items {
+			if err := encoder.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatCSV:
+		return writeCSV(w, inventory.Items)
+	case FormatCycloneDX:
+		return writeCycloneDX(w, inventory)
+	default:
+		return fmt.Errorf("unknown export format '%s'", format)
+	}
+}
+
+func writeCSV(w io.Writer, items []Item) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write(csvHeader)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		err = writer.Write([]string{
+			item.Kind,
+			item.Cluster,
+			item.Namespace,
+			item.Type,
+			item.Name,
+			item.columns.replicas,
+			strings.Join(item.columns.images, ";"),
+			strings.Join(item.columns.services, ";"),
+			joinLabels(item.columns.labels),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// joinLabels joins the labels as key=value pairs sorted by key
+func joinLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ";")
+}
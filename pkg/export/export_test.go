@@ -0,0 +1,117 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris"
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	namespaceModels "github.com/leanix/leanix-k8s-connector/pkg/iris/namespaces/models"
+	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func testInventory() Inventory {
+	discovery := iris.Discovery{
+		Namespaces: []namespaceModels.Data{{Cluster: namespaceModels.ClusterEcst{
+			Name:        "prod",
+			Namespace:   "web",
+			Deployments: []namespaceModels.DeploymentEcst{{ServiceName: "shop", Containers: []namespaceModels.Container{{Image: "shop:1.0"}}}},
+		}}},
+		Workloads: []workload.Data{{
+			Workload: workload.Workload{
+				Name:         "shop",
+				WorkloadType: "deployment",
+				Labels:       map[string]string{"tier": "web", "app": "shop"},
+				WorkloadProperties: workload.WorkloadProperties{
+					Replicas:   "2",
+					Containers: workload.Containers{{Image: "shop:1.0"}, {Image: "envoy:1.30"}},
+				},
+			},
+			NamespaceName: "web",
+			ServiceName:   "shop",
+			Services:      []workload.Service{{Name: "shop"}, {Name: "shop-admin"}},
+			Cluster:       workload.Cluster{Name: "prod"},
+		}},
+	}
+	config := models.KubernetesConfig{ID: "configId", Cluster: "prod"}
+	return NewInventory(config, discovery, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+}
+
+func TestReadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "cluster: prod\ndiscoveryMode: NAMESPACE,WORKLOAD\nblacklistedNamespaces:\n- kube-system\n"
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	config, err := ReadConfig(path)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", config.Cluster)
+	assert.True(t, config.DiscoveryMode.Namespaces())
+	assert.True(t, config.DiscoveryMode.Workloads())
+	assert.Equal(t, []string{"kube-system"}, config.BlackListedNamespaces)
+}
+
+func TestReadConfig_invalid(t *testing.T) {
+	dir := t.TempDir()
+	withoutCluster := filepath.Join(dir, "without-cluster.json")
+	assert.NoError(t, os.WriteFile(withoutCluster, []byte(`{"discoveryMode": "WORKLOAD"}`), 0o644))
+	unknownField := filepath.Join(dir, "unknown-field.yaml")
+	assert.NoError(t, os.WriteFile(unknownField, []byte("cluster: prod\nclusterName: prod\n"), 0o644))
+
+	_, err := ReadConfig(withoutCluster)
+	assert.ErrorContains(t, err, "needs a cluster name")
+	_, err = ReadConfig(unknownField)
+	assert.Error(t, err)
+}
+
+func TestWriteJSON(t *testing.T) {
+	var out bytes.Buffer
+
+	err := Write(&out, FormatJSON, testInventory())
+
+	assert.NoError(t, err)
+	var written map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &written))
+	assert.Equal(t, "prod", written["cluster"])
+	assert.Equal(t, "2024-05-01T12:00:00Z", written["exportedAt"])
+	assert.Len(t, written["items"], 2)
+}
+
+func TestWriteJSONLines(t *testing.T) {
+	var out bytes.Buffer
+
+	err := Write(&out, FormatJSONLines, testInventory())
+
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+	var item Item
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &item))
+	assert.Equal(t, KindWorkload, item.Kind)
+	assert.Equal(t, "deployment", item.Type)
+	assert.Equal(t, "shop", item.Name)
+	assert.Equal(t, "web", item.Namespace)
+}
+
+func TestWriteCSV(t *testing.T) {
+	var out bytes.Buffer
+
+	err := Write(&out, FormatCSV, testInventory())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "kind,cluster,namespace,type,name,replicas,images,services,labels\n"+
+		"namespace,prod,web,namespace,web,,shop:1.0,shop,\n"+
+		"workload,prod,web,deployment,shop,2,envoy:1.30;shop:1.0,shop;shop-admin,app=shop;tier=web\n", out.String())
+}
+
+func TestWrite_unknownFormat(t *testing.T) {
+	err := Write(&bytes.Buffer{}, "xml", testInventory())
+
+	assert.ErrorContains(t, err, "unknown export format 'xml'")
+}
@@ -0,0 +1,116 @@
+package image
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	DefaultRegistry = "docker.io"
+	DefaultTag      = "latest"
+	// officialRepositoryPrefix is the namespace of the official images on Docker Hub
+	officialRepositoryPrefix = "library/"
+)
+
+var digestPattern = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-zA-Z0-9=_-]+$`)
+
+// hexDigestPattern only accepts hex encoded digests, which tells a digest apart from name:tag
+var hexDigestPattern = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-f0-9]{32,}$`)
+
+// Reference is a container image reference split into its parts
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// Parse splits an image reference like registry:5000/team/app:1.2@sha256:... into registry,
+// repository, tag and digest. Docker Hub short names are normalised the way the container runtime
+// resolves them, e.g. nginx becomes docker.io/library/nginx:latest.
+func Parse(ref string) (Reference, error) {
+	reference := Reference{}
+	remainder := strings.TrimSpace(ref)
+	if remainder == "" {
+		return reference, fmt.Errorf("image reference must not be empty")
+	}
+
+	if i := strings.Index(remainder, "@"); i >= 0 {
+		reference.Digest = remainder[i+1:]
+		remainder = remainder[:i]
+		if !digestPattern.MatchString(reference.Digest) {
+			return Reference{}, fmt.Errorf("invalid digest '%s' in image reference '%s'", reference.Digest, ref)
+		}
+	}
+
+	// a colon after the last slash separates the tag, colons before are registry ports
+	if i := strings.LastIndex(remainder, ":"); i > strings.LastIndex(remainder, "/") {
+		reference.Tag = remainder[i+1:]
+		remainder = remainder[:i]
+		if reference.Tag == "" {
+			return Reference{}, fmt.Errorf("empty tag in image reference '%s'", ref)
+		}
+	}
+
+	if i := strings.Index(remainder, "/"); i >= 0 && isRegistry(remainder[:i]) {
+		reference.Registry = remainder[:i]
+		reference.Repository = remainder[i+1:]
+	} else {
+		reference.Registry = DefaultRegistry
+		reference.Repository = remainder
+	}
+	if reference.Repository == "" {
+		return Reference{}, fmt.Errorf("missing repository in image reference '%s'", ref)
+	}
+
+	if reference.Registry == "index.docker.io" || reference.Registry == "registry-1.docker.io" {
+		reference.Registry = DefaultRegistry
+	}
+	if reference.Registry == DefaultRegistry && !strings.Contains(reference.Repository, "/") {
+		reference.Repository = officialRepositoryPrefix + reference.Repository
+	}
+	if reference.Tag == "" && reference.Digest == "" {
+		reference.Tag = DefaultTag
+	}
+	return reference, nil
+}
+
+// Name returns the fully qualified repository name including the registry
+func (r Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// String returns the normalised image reference
+func (r Reference) String() string {
+	s := r.Name()
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// DigestFromImageID returns the digest of the image ID a container runtime reports in the pod
+// status, e.g. docker-pullable://nginx@sha256:..., docker://sha256:... or sha256:... Unknown formats return "".
+func DigestFromImageID(imageID string) string {
+	digest := imageID
+	if i := strings.Index(digest, "://"); i >= 0 {
+		digest = digest[i+3:]
+	}
+	if i := strings.LastIndex(digest, "@"); i >= 0 {
+		digest = digest[i+1:]
+	}
+	if !hexDigestPattern.MatchString(digest) {
+		return ""
+	}
+	return digest
+}
+
+// isRegistry tells whether the first path component of a reference is a registry host rather
+// than a Docker Hub namespace, following the rules of the docker reference implementation
+func isRegistry(component string) bool {
+	return strings.ContainsAny(component, ".:") || component == "localhost"
+}
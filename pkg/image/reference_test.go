@@ -0,0 +1,70 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	digest := "sha256:0000000000000000000000000000000000000000000000000000000000000001"
+	tests := []struct {
+		ref      string
+		expected Reference
+	}{
+		{"nginx", Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{"nginx:1.25", Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "1.25"}},
+		{"bitnami/redis:7.2", Reference{Registry: "docker.io", Repository: "bitnami/redis", Tag: "7.2"}},
+		{"index.docker.io/bitnami/redis", Reference{Registry: "docker.io", Repository: "bitnami/redis", Tag: "latest"}},
+		{"registry:5000/app:1.2", Reference{Registry: "registry:5000", Repository: "app", Tag: "1.2"}},
+		{"registry:5000/team/app", Reference{Registry: "registry:5000", Repository: "team/app", Tag: "latest"}},
+		{"localhost/app:dev", Reference{Registry: "localhost", Repository: "app", Tag: "dev"}},
+		{"ghcr.io/leanix/app@" + digest, Reference{Registry: "ghcr.io", Repository: "leanix/app", Digest: digest}},
+		{"ghcr.io/leanix/app:1.0@" + digest, Reference{Registry: "ghcr.io", Repository: "leanix/app", Tag: "1.0", Digest: digest}},
+		{"mcr.microsoft.com/dotnet/aspnet:8.0", Reference{Registry: "mcr.microsoft.com", Repository: "dotnet/aspnet", Tag: "8.0"}},
+	}
+	for _, test := range tests {
+		t.Run(test.ref, func(t *testing.T) {
+			reference, err := Parse(test.ref)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, reference)
+		})
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	for _, ref := range []string{"", "app:", "app@", "app@sha256", "registry:5000/"} {
+		t.Run(ref, func(t *testing.T) {
+			_, err := Parse(ref)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestReferenceString(t *testing.T) {
+	reference, err := Parse("registry:5000/app:1.2@sha256:abc")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "registry:5000/app", reference.Name())
+	assert.Equal(t, "registry:5000/app:1.2@sha256:abc", reference.String())
+}
+
+func TestDigestFromImageID(t *testing.T) {
+	digest := "sha256:0000000000000000000000000000000000000000000000000000000000000001"
+	tests := map[string]string{
+		"docker-pullable://nginx@" + digest: digest,
+		"docker.io/library/nginx@" + digest: digest,
+		digest:                              digest,
+		"docker://" + digest:                digest,
+		"docker://nginx:latest":             "",
+		"":                                  "",
+		"ghcr.io/leanix/app:1.0@" + digest:  digest,
+	}
+	for imageID, expected := range tests {
+		t.Run(imageID, func(t *testing.T) {
+			assert.Equal(t, expected, DigestFromImageID(imageID))
+		})
+	}
+}
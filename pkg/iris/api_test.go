@@ -1,13 +1,23 @@
 package iris
 
 import (
+	"context"
+	"fmt"
 	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/services"
+	"github.com/leanix/leanix-k8s-connector/pkg/leanix"
 	"github.com/leanix/leanix-k8s-connector/pkg/logger"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func setup() {
@@ -27,9 +37,9 @@ func TestGetConfiguration200(t *testing.T) {
 	defer server.Close()
 
 	// Use Client & URL from our local test server
-	api := services.NewIrisApi(server.Client(), "kind-test", server.URL, "token")
+	api := services.NewIrisApi(server.Client(), "kind-test", server.URL, leanix.StaticTokenSource("token"), services.RetryPolicy{})
 
-	configuration, err := api.GetConfiguration("test-config")
+	configuration, err := api.GetConfiguration(context.Background(), "test-config")
 	assert.NoError(t, err)
 	assert.Equal(t, "OK", string(configuration))
 }
@@ -48,9 +58,9 @@ func TestPostResults200(t *testing.T) {
 	defer server.Close()
 
 	// Use Client & URL from our local test server
-	api := services.NewIrisApi(server.Client(), "kind-test", server.URL, "token")
+	api := services.NewIrisApi(server.Client(), "kind-test", server.URL, leanix.StaticTokenSource("token"), services.RetryPolicy{})
 	results := []byte("test-results")
-	err := api.PostEcstResults(results)
+	err := api.PostEcstResults(context.Background(), results)
 	assert.NoError(t, err)
 }
 
@@ -64,8 +74,249 @@ func TestPostResultsError(t *testing.T) {
 	defer server.Close()
 
 	// Use Client & URL from our local test server
-	api := services.NewIrisApi(server.Client(), "kind-test", server.URL, "token")
+	api := services.NewIrisApi(server.Client(), "kind-test", server.URL, leanix.StaticTokenSource("token"), services.RetryPolicy{})
 	results := []byte("test-results")
-	err := api.PostEcstResults(results)
+	err := api.PostEcstResults(context.Background(), results)
 	assert.Equal(t, "posting ECST results status [500 Internal Server Error] could not be processed: 'Exception'", err.Error())
 }
+
+var testRetryPolicy = services.RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     10 * time.Millisecond,
+}
+
+// failingServer answers the first requests with the given status codes and succeeds afterwards
+func failingServer(requests *int32, statusCodes ...int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		attempt := int(atomic.AddInt32(requests, 1))
+		if attempt <= len(statusCodes) {
+			rw.Header().Set("Retry-After", "0")
+			rw.WriteHeader(statusCodes[attempt-1])
+			rw.Write([]byte(`Exception`))
+			return
+		}
+		rw.Write([]byte(`OK`))
+	}))
+}
+
+func TestGetConfigurationRetried(t *testing.T) {
+	setup()
+	var requests int32
+	server := failingServer(&requests, http.StatusBadGateway, http.StatusServiceUnavailable)
+	defer server.Close()
+	api := services.NewIrisApi(server.Client(), "kind-test", server.URL, leanix.StaticTokenSource("token"), testRetryPolicy)
+
+	configuration, err := api.GetConfiguration(context.Background(), "test-config")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", string(configuration))
+	assert.Equal(t, int32(3), requests)
+}
+
+func TestGetConfigurationRetriesExhausted(t *testing.T) {
+	setup()
+	var requests int32
+	server := failingServer(&requests, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	defer server.Close()
+	api := services.NewIrisApi(server.Client(), "kind-test", server.URL, leanix.StaticTokenSource("token"), testRetryPolicy)
+
+	_, err := api.GetConfiguration(context.Background(), "test-config")
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(3), requests)
+}
+
+func TestGetConfigurationNotRetriedOnClientError(t *testing.T) {
+	setup()
+	var requests int32
+	server := failingServer(&requests, http.StatusNotFound)
+	defer server.Close()
+	api := services.NewIrisApi(server.Client(), "kind-test", server.URL, leanix.StaticTokenSource("token"), testRetryPolicy)
+
+	_, err := api.GetConfiguration(context.Background(), "test-config")
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), requests)
+}
+
+func TestGetScanResultsRetriedOnNetworkError(t *testing.T) {
+	setup()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			// drop the connection without an answer
+			conn, _, err := rw.(http.Hijacker).Hijack()
+			assert.NoError(t, err)
+			conn.Close()
+			return
+		}
+		rw.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+	api := services.NewIrisApi(server.Client(), "kind-test", server.URL, leanix.StaticTokenSource("token"), testRetryPolicy)
+
+	results, err := api.GetScanResults(context.Background(), "test-config-id")
+
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+	assert.Equal(t, int32(2), requests)
+}
+
+func TestPostResultsRetriedWithSameBody(t *testing.T) {
+	setup()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requestData, err := io.ReadAll(req.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "test-results", string(requestData))
+		assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+		if atomic.AddInt32(&requests, 1) == 1 {
+			rw.Header().Set("Retry-After", "0")
+			rw.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+	api := services.NewIrisApi(server.Client(), "kind-test", server.URL, leanix.StaticTokenSource("token"), testRetryPolicy)
+
+	err := api.PostEcstResults(context.Background(), []byte("test-results"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), requests)
+}
+
+func TestPostStatusOnlyRetriedWhenRejected(t *testing.T) {
+	setup()
+	var requests int32
+	server := failingServer(&requests, http.StatusServiceUnavailable, http.StatusInternalServerError)
+	defer server.Close()
+	api := services.NewIrisApi(server.Client(), "kind-test", server.URL, leanix.StaticTokenSource("token"), testRetryPolicy)
+
+	err := api.PostStatus(context.Background(), []byte("test-status"))
+
+	// the 503 is retried, the 500 may have been processed and is not posted again
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), requests)
+}
+
+func TestPostResultsNotRetriedAfterCancel(t *testing.T) {
+	setup()
+	var requests int32
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		cancel()
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	slowRetries := services.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour}
+	api := services.NewIrisApi(server.Client(), "kind-test", server.URL, leanix.StaticTokenSource("token"), slowRetries)
+
+	err := api.PostEcstResults(ctx, []byte("test-results"))
+
+	// the backoff is not waited for once the run is cancelled
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(1), requests)
+}
+
+func TestPostStatusAfterCancel(t *testing.T) {
+	setup()
+	var requests int32
+	server := failingServer(&requests)
+	defer server.Close()
+	api := services.NewIrisApi(server.Client(), "kind-test", server.URL, leanix.StaticTokenSource("token"), testRetryPolicy)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := api.PostStatus(ctx, []byte("test-status"))
+
+	// the status of an aborted run is still posted
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), requests)
+}
+
+// countingTokenSource hands out token-1, token-2, ... whenever the last token was invalidated
+type countingTokenSource struct {
+	tokens int
+	valid  bool
+}
+
+func (s *countingTokenSource) Token() (string, error) {
+	if !s.valid {
+		s.tokens++
+		s.valid = true
+	}
+	return fmt.Sprintf("token-%d", s.tokens), nil
+}
+
+func (s *countingTokenSource) Invalidate(string) {
+	s.valid = false
+}
+
+func TestGetConfigurationReauthenticated(t *testing.T) {
+	setup()
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer token-2" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		rw.Write([]byte(`OK`))
+	}))
+	defer server.Close()
+	tokenSource := &countingTokenSource{}
+	api := services.NewIrisApi(server.Client(), "kind-test", server.URL, tokenSource, services.RetryPolicy{})
+
+	configuration, err := api.GetConfiguration(context.Background(), "test-config")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", string(configuration))
+	assert.Equal(t, 2, tokenSource.tokens)
+}
+
+func TestGetConfigurationReauthenticatedOnlyOnce(t *testing.T) {
+	setup()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		rw.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	api := services.NewIrisApi(server.Client(), "kind-test", server.URL, &countingTokenSource{}, testRetryPolicy)
+
+	_, err := api.GetConfiguration(context.Background(), "test-config")
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), requests)
+}
+
+func TestGetConfigurationTraced(t *testing.T) {
+	setup()
+	recorder := tracetest.NewSpanRecorder()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	}()
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		traceparent = req.Header.Get("traceparent")
+		rw.Write([]byte(`OK`))
+	}))
+	defer server.Close()
+	api := services.NewIrisApi(server.Client(), "kind-test", server.URL, leanix.StaticTokenSource("token"), services.RetryPolicy{})
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "Scan")
+
+	_, err := api.GetConfiguration(ctx, "test-config")
+	parent.End()
+
+	assert.NoError(t, err)
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "iris configurations", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Contains(t, traceparent, parent.SpanContext().TraceID().String())
+	assert.Contains(t, traceparent, spans[0].SpanContext().SpanID().String())
+}
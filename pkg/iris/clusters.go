@@ -0,0 +1,77 @@
+package iris
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+	"github.com/leanix/leanix-k8s-connector/pkg/logger"
+)
+
+// ScanClusters scans the configurations of every cluster, at most parallelism clusters at the same
+// time. newScanner creates a scanner with a run id of its own which reports the given cluster name
+// instead of the one of the configuration unless it is empty. A failing cluster does not stop the
+// others, their errors are returned joined.
+func ScanClusters(ctx context.Context, newScanner func(clusterName string) Scanner, getKubernetesAPI kubernetes.GetKubernetesAPI, clusters []kubernetes.Cluster, parallelism int) error {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	errs := make([]error, len(clusters))
+	semaphore := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, cluster := range clusters {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, cluster kubernetes.Cluster) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			started := time.Now()
+			err := ScanConfigurations(ctx, clusterScanner(newScanner, cluster), getKubernetesAPI, cluster.Config, cluster.Configurations)
+			if err != nil {
+				logger.Errorf("Scan of cluster '%s' (context '%s') failed after %v: %v", clusterName(cluster), cluster.Context, time.Since(started), err)
+				errs[i] = fmt.Errorf("cluster '%s': %w", clusterName(cluster), err)
+				return
+			}
+			logger.Infof("Scan of cluster '%s' (context '%s') finished after %v for %d configurations", clusterName(cluster), cluster.Context, time.Since(started), len(cluster.Configurations))
+		}(i, cluster)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// WatchClusters watches the configurations of all clusters at the same time until ctx is cancelled.
+// The errors of the clusters are returned joined.
+func WatchClusters(ctx context.Context, newScanner func(clusterName string) Scanner, getKubernetesAPI kubernetes.GetKubernetesAPI, clusters []kubernetes.Cluster, resyncPeriod time.Duration) error {
+	errs := make([]error, len(clusters))
+	var wg sync.WaitGroup
+	for i, cluster := range clusters {
+		wg.Add(1)
+		go func(i int, cluster kubernetes.Cluster) {
+			defer wg.Done()
+			err := WatchConfigurations(ctx, clusterScanner(newScanner, cluster), getKubernetesAPI, cluster.Config, cluster.Configurations, resyncPeriod)
+			if err != nil {
+				logger.Errorf("Watch of cluster '%s' (context '%s') failed: %v", clusterName(cluster), cluster.Context, err)
+				errs[i] = fmt.Errorf("cluster '%s': %w", clusterName(cluster), err)
+			}
+		}(i, cluster)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func clusterScanner(newScanner func(clusterName string) Scanner, cluster kubernetes.Cluster) func() Scanner {
+	return func() Scanner {
+		return newScanner(cluster.Cluster)
+	}
+}
+
+// clusterName names the cluster in logs and errors, by the context if it has no name of its own
+func clusterName(cluster kubernetes.Cluster) string {
+	if cluster.Cluster != "" {
+		return cluster.Cluster
+	}
+	return cluster.Context
+}
@@ -0,0 +1,140 @@
+package iris
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	namespaceModels "github.com/leanix/leanix-k8s-connector/pkg/iris/namespaces/models"
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestScanClusters(t *testing.T) {
+	setup()
+	api := &fakeIrisApi{
+		configurations: map[string]string{
+			"eu": `{"id": "euId", "cluster": "configured"}`,
+			"us": `{"id": "usId", "cluster": "configured"}`,
+		},
+	}
+	var running, maxRunning int32
+	var mu sync.Mutex
+	clients := map[string]*fake.Clientset{}
+	for _, host := range []string{"eu", "us", "ap"} {
+		client := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web-" + host}})
+		client.PrependReactor("list", "nodes", func(k8stesting.Action) (bool, runtime.Object, error) {
+			now := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			mu.Lock()
+			if now > maxRunning {
+				maxRunning = now
+			}
+			mu.Unlock()
+			return false, nil, nil
+		})
+		clients[host] = client
+	}
+	getKubernetesAPI := func(config *rest.Config) (*kubernetes.API, error) {
+		return &kubernetes.API{Client: clients[config.Host]}, nil
+	}
+	newScanner := func(clusterName string) Scanner {
+		s := newTestScanner(api)
+		s.clusterName = clusterName
+		return s
+	}
+	clusters := []kubernetes.Cluster{
+		{ClusterMapping: kubernetes.ClusterMapping{Context: "eu", Cluster: "prod-eu", Configurations: []string{"eu"}}, Config: &rest.Config{Host: "eu"}},
+		{ClusterMapping: kubernetes.ClusterMapping{Context: "us", Configurations: []string{"us"}}, Config: &rest.Config{Host: "us"}},
+		{ClusterMapping: kubernetes.ClusterMapping{Context: "ap", Configurations: []string{"missing"}}, Config: &rest.Config{Host: "ap"}},
+	}
+
+	err := ScanClusters(context.Background(), newScanner, getKubernetesAPI, clusters, 2)
+
+	// the cluster with the missing configuration fails on its own
+	assert.ErrorContains(t, err, "cluster 'ap'")
+	assert.NotContains(t, err.Error(), "cluster 'prod-eu'")
+	assert.LessOrEqual(t, maxRunning, int32(2))
+	clusterNames := map[string]string{}
+	for _, event := range api.posted {
+		if event.HeaderProperties.Class == models.EventClassNamespace {
+			clusterNames[event.Body.State.Name] = event.Body.State.SourceInstance
+		}
+	}
+	assert.Equal(t, map[string]string{"web-eu": "cluster/prod-eu", "web-us": "cluster/configured"}, clusterNames)
+}
+
+func TestScanClusters_sharedConfiguration(t *testing.T) {
+	setup()
+	known := func(cluster string, namespace string) models.DiscoveryEvent {
+		data := namespaceModels.Data{Cluster: namespaceModels.ClusterEcst{Name: cluster, Namespace: namespace}}
+		id := namespaceModels.GenerateId("workspaceId", "configId", data)
+		return namespaceModels.CreateEcstDiscoveryEvent(models.EVENT_TYPE_STATE, "", id, data, "workspaceId", "configId")
+	}
+	api := &fakeIrisApi{
+		configurations: map[string]string{"shared": `{"id": "configId", "cluster": "configured"}`},
+		// the configuration holds the items of both clusters
+		results: []models.DiscoveryEvent{known("prod-eu", "web"), known("prod-eu", "old"), known("prod-us", "web")},
+	}
+	clients := map[string]*fake.Clientset{}
+	for _, host := range []string{"eu", "us"} {
+		clients[host] = fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web"}})
+	}
+	getKubernetesAPI := func(config *rest.Config) (*kubernetes.API, error) {
+		return &kubernetes.API{Client: clients[config.Host]}, nil
+	}
+	newScanner := func(clusterName string) Scanner {
+		s := newTestScanner(api)
+		s.clusterName = clusterName
+		return s
+	}
+	clusters := []kubernetes.Cluster{
+		{ClusterMapping: kubernetes.ClusterMapping{Context: "eu", Cluster: "prod-eu", Configurations: []string{"shared"}}, Config: &rest.Config{Host: "eu"}},
+		{ClusterMapping: kubernetes.ClusterMapping{Context: "us", Cluster: "prod-us", Configurations: []string{"shared"}}, Config: &rest.Config{Host: "us"}},
+	}
+
+	err := ScanClusters(context.Background(), newScanner, getKubernetesAPI, clusters, 2)
+
+	assert.NoError(t, err)
+	// each cluster only deletes its own items which are gone
+	var deleted []string
+	for _, event := range api.posted {
+		if event.HeaderProperties.Action == models.EventActionDeleted {
+			deleted = append(deleted, event.Body.State.SourceInstance+" "+event.Body.State.Name)
+		}
+	}
+	assert.Equal(t, []string{"cluster/prod-eu old"}, deleted)
+}
+
+func TestScan_renamedCluster(t *testing.T) {
+	setup()
+	data := namespaceModels.Data{Cluster: namespaceModels.ClusterEcst{Name: "prod", Namespace: "web"}}
+	id := namespaceModels.GenerateId("workspaceId", "configId", data)
+	api := &fakeIrisApi{
+		// the cluster was renamed in the configuration since the last scan
+		configurations: map[string]string{"config": `{"id": "configId", "cluster": "prod-eu"}`},
+		results:        []models.DiscoveryEvent{namespaceModels.CreateEcstDiscoveryEvent(models.EVENT_TYPE_STATE, "", id, data, "workspaceId", "configId")},
+	}
+	client := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web"}})
+	getKubernetesAPI := func(*rest.Config) (*kubernetes.API, error) {
+		return &kubernetes.API{Client: client}, nil
+	}
+
+	err := newTestScanner(api).Scan(context.Background(), getKubernetesAPI, nil, "config")
+
+	assert.NoError(t, err)
+	// without a cluster name override the items of the old name are deleted
+	actions := map[string]string{}
+	for _, event := range api.posted {
+		actions[event.Body.State.SourceInstance] = event.HeaderProperties.Action
+	}
+	assert.Equal(t, map[string]string{"cluster/prod": models.EventActionDeleted, "cluster/prod-eu": models.EventActionCreated}, actions)
+}
@@ -1,8 +1,80 @@
 package models
 
+import (
+	"encoding/json"
+	"strings"
+)
+
+const (
+	DiscoveryModeNamespace string = "NAMESPACE"
+	DiscoveryModeWorkload  string = "WORKLOAD"
+)
+
 type KubernetesConfig struct {
-	ID                    string   `json:"id"`
-	Cluster               string   `json:"cluster"`
-	BlackListedNamespaces []string `json:"blacklistedNamespaces"`
-	DiscoveryMode         string   `json:"discoveryMode"`
+	ID                    string         `json:"id"`
+	Cluster               string         `json:"cluster"`
+	BlackListedNamespaces []string       `json:"blacklistedNamespaces"`
+	DiscoveryMode         DiscoveryModes `json:"discoveryMode"`
+	// CustomWorkloads are custom resources mapped as workloads in the workload discovery mode
+	CustomWorkloads []CustomWorkload `json:"customWorkloads"`
+}
+
+// CustomWorkload declares a custom resource, e.g. an Argo Rollout, which is mapped as a workload.
+// The fields of its objects are read with JSONPath expressions like {.spec.replicas}, the braces
+// may be left out.
+type CustomWorkload struct {
+	Group    string `json:"group"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+	// WorkloadType is the type of the mapped workloads, the kind of the objects by default
+	WorkloadType string `json:"workloadType"`
+	// Name is the name of the mapped workloads, the name of the objects by default
+	Name     string `json:"name"`
+	Replicas string `json:"replicas"`
+	// PodTemplate selects the pod template of the objects, its containers and labels
+	PodTemplate string `json:"podTemplate"`
+	// Images selects the images of the objects without a pod template
+	Images string `json:"images"`
+}
+
+// DiscoveryModes is the set of discovery modes of a configuration. It is read from a single mode,
+// a comma separated list of modes or an array of modes.
+type DiscoveryModes []string
+
+func (m *DiscoveryModes) UnmarshalJSON(data []byte) error {
+	var modes []string
+	if err := json.Unmarshal(data, &modes); err != nil {
+		var mode string
+		if err := json.Unmarshal(data, &mode); err != nil {
+			return err
+		}
+		modes = strings.Split(mode, ",")
+	}
+	*m = DiscoveryModes{}
+	for _, mode := range modes {
+		mode = strings.ToUpper(strings.TrimSpace(mode))
+		if mode != "" && !m.has(mode) {
+			*m = append(*m, mode)
+		}
+	}
+	return nil
+}
+
+// Namespaces tells whether namespaces are discovered, which is the default without the workload mode
+func (m DiscoveryModes) Namespaces() bool {
+	return m.has(DiscoveryModeNamespace) || !m.has(DiscoveryModeWorkload)
+}
+
+// Workloads tells whether workloads are discovered
+func (m DiscoveryModes) Workloads() bool {
+	return m.has(DiscoveryModeWorkload)
+}
+
+func (m DiscoveryModes) has(mode string) bool {
+	for _, item := range m {
+		if item == mode {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscoveryModes(t *testing.T) {
+	cases := []struct {
+		json       string
+		modes      DiscoveryModes
+		namespaces bool
+		workloads  bool
+	}{
+		{json: `""`, modes: DiscoveryModes{}, namespaces: true},
+		{json: `"WORKLOAD"`, modes: DiscoveryModes{"WORKLOAD"}, workloads: true},
+		{json: `"namespace, workload"`, modes: DiscoveryModes{"NAMESPACE", "WORKLOAD"}, namespaces: true, workloads: true},
+		{json: `["WORKLOAD", "NAMESPACE", "WORKLOAD"]`, modes: DiscoveryModes{"WORKLOAD", "NAMESPACE"}, namespaces: true, workloads: true},
+	}
+	for _, c := range cases {
+		var config KubernetesConfig
+		err := json.Unmarshal([]byte(`{"discoveryMode": `+c.json+`}`), &config)
+
+		assert.NoError(t, err, c.json)
+		assert.Equal(t, c.modes, config.DiscoveryMode, c.json)
+		assert.Equal(t, c.namespaces, config.DiscoveryMode.Namespaces(), c.json)
+		assert.Equal(t, c.workloads, config.DiscoveryMode.Workloads(), c.json)
+	}
+}
+
+func TestDiscoveryModes_missing(t *testing.T) {
+	var config KubernetesConfig
+	err := json.Unmarshal([]byte(`{"id": "config"}`), &config)
+
+	assert.NoError(t, err)
+	assert.True(t, config.DiscoveryMode.Namespaces())
+	assert.False(t, config.DiscoveryMode.Workloads())
+}
@@ -1,5 +1,7 @@
 package models
 
+import "github.com/leanix/leanix-k8s-connector/pkg/diff"
+
 type DiscoveryEvent struct {
 	HeaderProperties HeaderProperties `json:"properties"`
 	Body             DiscoveryBody    `json:"body"`
@@ -7,6 +9,8 @@ type DiscoveryEvent struct {
 
 type DiscoveryBody struct {
 	State State `json:"state"`
+	// Changes are the fields of an updated item that differ from the previous state
+	Changes []diff.Change `json:"changes,omitempty"`
 }
 
 type State struct {
@@ -2,59 +2,59 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	"github.com/leanix/leanix-k8s-connector/pkg/leanix"
 	"github.com/leanix/leanix-k8s-connector/pkg/logger"
+	"github.com/leanix/leanix-k8s-connector/pkg/metrics"
+	"github.com/leanix/leanix-k8s-connector/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 )
 
 type IrisApi interface {
-	GetConfiguration(configurationName string) ([]byte, error)
-	GetScanResults(configurationId string) ([]models.DiscoveryEvent, error)
-	PostEcstResults(ecstResults []byte) error
-	PostStatus(status []byte) error
+	GetConfiguration(ctx context.Context, configurationName string) ([]byte, error)
+	GetScanResults(ctx context.Context, configurationId string) ([]models.DiscoveryEvent, error)
+	PostEcstResults(ctx context.Context, ecstResults []byte) error
+	PostStatus(ctx context.Context, status []byte) error
 }
 
 type irisApi struct {
-	client *http.Client
-	kind   string
-	uri    string
-	token  string
+	client      *http.Client
+	kind        string
+	uri         string
+	tokenSource leanix.TokenSource
+	retryPolicy RetryPolicy
 }
 
-func NewIrisApi(client *http.Client, kind string, uri string, token string) IrisApi {
+func NewIrisApi(client *http.Client, kind string, uri string, tokenSource leanix.TokenSource, retryPolicy RetryPolicy) IrisApi {
 	protocol := ""
 	if !strings.Contains(uri, "http") {
 		protocol = "https://"
 	}
 	return &irisApi{
-		client: client,
-		kind:   kind,
-		uri:    fmt.Sprintf("%s%s", protocol, uri),
-		token:  token,
+		client:      client,
+		kind:        kind,
+		uri:         fmt.Sprintf("%s%s", protocol, uri),
+		tokenSource: tokenSource,
+		retryPolicy: retryPolicy,
 	}
 }
 
-func (a *irisApi) GetConfiguration(configurationName string) ([]byte, error) {
+func (a *irisApi) GetConfiguration(ctx context.Context, configurationName string) ([]byte, error) {
 	if configurationName == "" {
 		return nil, errors.New("configuration name should not be null or empty")
 	}
 	configUrl := fmt.Sprintf("%s/services/vsm-iris/v1/configurations/kubernetes/%s", a.uri, configurationName)
-	req, err := http.NewRequest("GET", configUrl, nil)
-	if err != nil {
-		logger.Errorf("Error while creating request to retrieve configuration from %s: %v", configurationName, err)
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Bearer "+a.token)
-
-	// Execute request
-	resp, err := a.client.Do(req)
+	resp, err := a.send(ctx, "GET", configUrl, "configurations", nil, retryIdempotent)
 	if err != nil {
+		logger.Errorf("Error while retrieving configuration %s: %v", configurationName, err)
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -72,21 +72,14 @@ func (a *irisApi) GetConfiguration(configurationName string) ([]byte, error) {
 	return responseData, nil
 }
 
-func (a *irisApi) GetScanResults(configurationId string) ([]models.DiscoveryEvent, error) {
+func (a *irisApi) GetScanResults(ctx context.Context, configurationId string) ([]models.DiscoveryEvent, error) {
 	if configurationId == "" {
 		return nil, errors.New("configuration id should not be null or empty")
 	}
 	configUrl := fmt.Sprintf("%s/services/vsm-iris/v1/configurations/%s/results", a.uri, configurationId)
-	req, err := http.NewRequest("GET", configUrl, nil)
-	if err != nil {
-		logger.Errorf("Error while creating request to retrieve latestResults from config '%s': %v", configurationId, err)
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Bearer "+a.token)
-
-	// Execute request
-	resp, err := a.client.Do(req)
+	resp, err := a.send(ctx, "GET", configUrl, "results", nil, retryIdempotent)
 	if err != nil {
+		logger.Errorf("Error while retrieving latestResults from config '%s': %v", configurationId, err)
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -114,25 +107,16 @@ func (a *irisApi) GetScanResults(configurationId string) ([]models.DiscoveryEven
 }
 
 // Send request to ECST Endpoint
-func (a *irisApi) PostEcstResults(ecstResults []byte) error {
+func (a *irisApi) PostEcstResults(ctx context.Context, ecstResults []byte) error {
 	resultUrl := fmt.Sprintf("%s/services/vsm-iris/v1/results/ecst", a.uri)
-	postReq, err := http.NewRequest("POST", resultUrl, nil)
-	if err != nil {
-		logger.Errorf("Error creating request to post ECST results: %v", err)
-		return err
-	}
-	postReq.Header.Set("Content-Type", "application/json")
-	postReq.Header.Set("Authorization", "Bearer "+a.token)
-	postReq.Body = io.NopCloser(bytes.NewBuffer(ecstResults))
-
-	// Execute request
-	resp, err := a.client.Do(postReq)
+	resp, err := a.send(ctx, "POST", resultUrl, "ecst", ecstResults, retryIdempotent)
 	if err != nil {
+		logger.Errorf("Error posting ECST results: %v", err)
 		return err
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
-		responseData, readErr := ioutil.ReadAll(resp.Body)
-		defer resp.Body.Close()
+		responseData, readErr := io.ReadAll(resp.Body)
 		if readErr != nil {
 			return readErr
 		}
@@ -143,23 +127,18 @@ func (a *irisApi) PostEcstResults(ecstResults []byte) error {
 	return nil
 }
 
-func (a *irisApi) PostStatus(status []byte) error {
+// PostStatus posts a status or admin log. Cancelling ctx does not cancel it, so the status of an
+// aborted run is still posted.
+func (a *irisApi) PostStatus(ctx context.Context, status []byte) error {
 	resultUrl := fmt.Sprintf("%s/services/vsm-iris/v1/status", a.uri)
-	postReq, err := http.NewRequest("POST", resultUrl, nil)
-	postReq.Header.Set("Content-Type", "application/json")
-	postReq.Header.Set("Authorization", "Bearer "+a.token)
-	postReq.Body = ioutil.NopCloser(bytes.NewBuffer(status))
+	resp, err := a.send(context.WithoutCancel(ctx), "POST", resultUrl, "status", status, retryRejected)
 	if err != nil {
 		logger.Errorf("Error while posting status: %v", err)
 		return err
 	}
-	resp, err := http.DefaultClient.Do(postReq)
-	if err != nil {
-		return err
-	}
+	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
-		responseData, readErr := ioutil.ReadAll(resp.Body)
-		defer resp.Body.Close()
+		responseData, readErr := io.ReadAll(resp.Body)
 		if readErr != nil {
 			return readErr
 		}
@@ -169,3 +148,75 @@ func (a *irisApi) PostStatus(status []byte) error {
 	logger.Infof("Status Event posted successfully [%s]", resp.Status)
 	return nil
 }
+
+// sendOnce sends the request once, in a span of its own
+func (a *irisApi) sendOnce(ctx context.Context, method string, url string, endpoint string, body []byte, token string, attempt int) (resp *http.Response, err error) {
+	ctx, span := tracing.Start(ctx, "iris "+endpoint,
+		attribute.String("http.request.method", method),
+		attribute.String("url.full", url),
+		attribute.Int("http.request.resend_count", attempt-1))
+	defer func() { tracing.End(span, err) }()
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	tracing.Inject(ctx, req.Header)
+
+	started := time.Now()
+	resp, err = a.client.Do(req)
+	metrics.ObserveIrisRequest(method, endpoint, resp, time.Since(started))
+	if resp != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	}
+	return resp, err
+}
+
+// send executes the request and sends it again as long as the retry condition holds and the retry
+// policy has attempts left. The response of the last attempt is returned and has to be closed.
+// A request rejected with 401 is sent once more with a freshly fetched token. Every attempt is
+// recorded in the metrics under the name of the endpoint and traced as a span of the trace in ctx,
+// whose context is propagated to Iris. Cancelling ctx cancels the request and the wait for the next
+// attempt.
+func (a *irisApi) send(ctx context.Context, method string, url string, endpoint string, body []byte, retry retryCondition) (*http.Response, error) {
+	attempts := max(a.retryPolicy.MaxAttempts, 1)
+	reauthenticated := false
+	for attempt := 1; ; attempt++ {
+		token, err := a.tokenSource.Token()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := a.sendOnce(ctx, method, url, endpoint, body, token, attempt)
+		if err == nil && resp.StatusCode == http.StatusUnauthorized && !reauthenticated {
+			logger.Infof("%s %s was rejected with status [%s], retrying with a new access token", method, url, resp.Status)
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			a.tokenSource.Invalidate(token)
+			reauthenticated = true
+			// the rejected attempt does not count against the retry policy
+			attempt--
+			continue
+		}
+		if attempt >= attempts || !retry(resp, err) {
+			return resp, err
+		}
+		delay := a.retryPolicy.delay(attempt, resp)
+		if err != nil {
+			logger.Infof("%s %s failed in attempt %d/%d, retrying in %v: %v", method, url, attempt, attempts, delay, err)
+		} else {
+			logger.Infof("%s %s failed in attempt %d/%d with status [%s], retrying in %v", method, url, attempt, attempts, resp.Status, delay)
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%s %s was not retried: %w", method, url, context.Cause(ctx))
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	"github.com/pkg/errors"
+)
+
+// BatchConfig bounds the ECST events posted to Iris in a single request
+type BatchConfig struct {
+	// MaxEvents is the maximum number of events in a batch, 0 means no limit
+	MaxEvents int
+	// MaxBytes is the maximum size of the JSON body of a batch, 0 means no limit. An event larger
+	// than MaxBytes is posted in a batch of its own.
+	MaxBytes int
+	// Concurrency is the number of batches posted at the same time, batches are posted one after
+	// the other below 2
+	Concurrency int
+}
+
+// DefaultBatchConfig keeps the requests well below common gateway body limits
+var DefaultBatchConfig = BatchConfig{
+	MaxEvents:   500,
+	MaxBytes:    4 * 1024 * 1024,
+	Concurrency: 1,
+}
+
+// ProcessReport is the outcome of posting the events of the discovered items
+type ProcessReport struct {
+	Batches BatchReport
+	// Updated are the posted update events, their bodies list the changed fields
+	Updated []models.DiscoveryEvent
+	// Changes are all posted created, updated and deleted events
+	Changes []models.DiscoveryEvent
+}
+
+// BatchResult is the outcome of posting a single batch of ECST events
+type BatchResult struct {
+	Index  int
+	Events int
+	Bytes  int
+	Err    error
+}
+
+// BatchReport holds the outcome of every batch in the order the batches were built
+type BatchReport []BatchResult
+
+// Failed returns the batches Iris did not accept
+func (r BatchReport) Failed() BatchReport {
+	failed := make(BatchReport, 0)
+	for _, result := range r {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Events returns the number of events over all batches
+func (r BatchReport) Events() int {
+	events := 0
+	for _, result := range r {
+		events += result.Events
+	}
+	return events
+}
+
+// Accepted returns the events of the batches Iris accepted, out of the posted events in the order
+// they were batched
+func (r BatchReport) Accepted(events []models.DiscoveryEvent) []models.DiscoveryEvent {
+	accepted := make([]models.DiscoveryEvent, 0, len(events))
+	offset := 0
+	for _, result := range r {
+		if result.Err == nil && offset+result.Events <= len(events) {
+			accepted = append(accepted, events[offset:offset+result.Events]...)
+		}
+		offset += result.Events
+	}
+	return accepted
+}
+
+// Err returns an error naming the failed batches, or nil if all batches were accepted
+func (r BatchReport) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d batches of ECST events were not accepted, first error: %w", len(failed), len(r), failed[0].Err)
+}
+
+// PostEcstBatches splits the events into batches bounded by config and posts them. A failing batch
+// does not stop the other batches, the outcome of each batch is returned in the report.
+func PostEcstBatches(ctx context.Context, irisApi IrisApi, events []models.DiscoveryEvent, config BatchConfig) (BatchReport, error) {
+	batches, err := createBatches(events, config)
+	if err != nil {
+		return nil, err
+	}
+	report := make(BatchReport, len(batches))
+	concurrency := config.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, batch ecstBatch) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			report[i] = BatchResult{
+				Index:  i,
+				Events: batch.events,
+				Bytes:  len(batch.body),
+				Err:    irisApi.PostEcstResults(ctx, batch.body),
+			}
+		}(i, batch)
+	}
+	wg.Wait()
+	return report, nil
+}
+
+type ecstBatch struct {
+	events int
+	body   []byte
+}
+
+// createBatches marshals every event on its own and joins them into JSON arrays within the limits
+func createBatches(events []models.DiscoveryEvent, config BatchConfig) ([]ecstBatch, error) {
+	batches := make([]ecstBatch, 0)
+	var current [][]byte
+	size := 0
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		body := append([]byte("["), bytes.Join(current, []byte(","))...)
+		batches = append(batches, ecstBatch{events: len(current), body: append(body, ']')})
+		current = nil
+		size = 0
+	}
+	for _, event := range events {
+		marshalled, err := json.Marshal(event)
+		if err != nil {
+			return nil, errors.Wrap(err, "Marshall scanned ECST services")
+		}
+		// brackets of the array and the comma before the event
+		grown := size + len(marshalled) + 1
+		if len(current) == 0 {
+			grown = len(marshalled) + 2
+		}
+		if len(current) > 0 && ((config.MaxEvents > 0 && len(current) >= config.MaxEvents) || (config.MaxBytes > 0 && grown > config.MaxBytes)) {
+			flush()
+			grown = len(marshalled) + 2
+		}
+		current = append(current, marshalled)
+		size = grown
+	}
+	flush()
+	return batches, nil
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingIrisApi records the posted ECST bodies and fails the posts listed in fail
+type recordingIrisApi struct {
+	IrisApi
+	mu     sync.Mutex
+	posted [][]models.DiscoveryEvent
+	fail   map[int]bool
+}
+
+func (a *recordingIrisApi) PostEcstResults(_ context.Context, ecstResults []byte) error {
+	var events []models.DiscoveryEvent
+	if err := json.Unmarshal(ecstResults, &events); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.posted = append(a.posted, events)
+	if a.fail[len(a.posted)-1] {
+		return errors.New("payload too large")
+	}
+	return nil
+}
+
+func testEvents(n int) []models.DiscoveryEvent {
+	events := make([]models.DiscoveryEvent, 0, n)
+	for i := 0; i < n; i++ {
+		events = append(events, models.DiscoveryEvent{HeaderProperties: models.HeaderProperties{Id: fmt.Sprintf("event-%d", i)}})
+	}
+	return events
+}
+
+func TestPostEcstBatches_maxEvents(t *testing.T) {
+	api := &recordingIrisApi{}
+
+	report, err := PostEcstBatches(context.Background(), api, testEvents(5), BatchConfig{MaxEvents: 2})
+
+	assert.NoError(t, err)
+	assert.NoError(t, report.Err())
+	assert.Len(t, report, 3)
+	assert.Equal(t, 5, report.Events())
+	assert.Len(t, api.posted, 3)
+	assert.Len(t, api.posted[0], 2)
+	assert.Len(t, api.posted[2], 1)
+	assert.Equal(t, "event-4", api.posted[2][0].HeaderProperties.Id)
+}
+
+func TestPostEcstBatches_maxBytes(t *testing.T) {
+	api := &recordingIrisApi{}
+	events := testEvents(4)
+	single, err := json.Marshal(events[:1])
+	assert.NoError(t, err)
+
+	// room for two events including the comma in between
+	report, err := PostEcstBatches(context.Background(), api, events, BatchConfig{MaxBytes: 2*len(single) - 1})
+
+	assert.NoError(t, err)
+	assert.Len(t, report, 2)
+	for _, result := range report {
+		assert.Equal(t, 2, result.Events)
+		assert.LessOrEqual(t, result.Bytes, 2*len(single)-1)
+	}
+}
+
+func TestPostEcstBatches_oversizedEvent(t *testing.T) {
+	api := &recordingIrisApi{}
+
+	report, err := PostEcstBatches(context.Background(), api, testEvents(2), BatchConfig{MaxBytes: 10})
+
+	assert.NoError(t, err)
+	// every event is posted on its own even though it exceeds the limit
+	assert.Len(t, report, 2)
+	assert.Len(t, api.posted, 2)
+}
+
+func TestPostEcstBatches_failedBatch(t *testing.T) {
+	api := &recordingIrisApi{fail: map[int]bool{1: true}}
+
+	report, err := PostEcstBatches(context.Background(), api, testEvents(3), BatchConfig{MaxEvents: 1})
+
+	assert.NoError(t, err)
+	// the batches after the failed one are posted anyway
+	assert.Len(t, api.posted, 3)
+	assert.Len(t, report.Failed(), 1)
+	assert.Equal(t, 1, report.Failed()[0].Index)
+	assert.EqualError(t, report.Err(), "1 of 3 batches of ECST events were not accepted, first error: payload too large")
+}
+
+func TestBatchReport_accepted(t *testing.T) {
+	events := testEvents(5)
+	report := BatchReport{
+		{Index: 0, Events: 2},
+		{Index: 1, Events: 2, Err: errors.New("payload too large")},
+		{Index: 2, Events: 1},
+	}
+
+	accepted := report.Accepted(events)
+
+	assert.Equal(t, []models.DiscoveryEvent{events[0], events[1], events[4]}, accepted)
+}
+
+func TestPostEcstBatches_concurrency(t *testing.T) {
+	api := &recordingIrisApi{}
+
+	report, err := PostEcstBatches(context.Background(), api, testEvents(10), BatchConfig{MaxEvents: 1, Concurrency: 4})
+
+	assert.NoError(t, err)
+	assert.NoError(t, report.Err())
+	assert.Len(t, api.posted, 10)
+	// the report keeps the order of the batches
+	for i, result := range report {
+		assert.Equal(t, i, result.Index)
+	}
+}
+
+func TestPostEcstBatches_noEvents(t *testing.T) {
+	api := &recordingIrisApi{}
+
+	report, err := PostEcstBatches(context.Background(), api, nil, DefaultBatchConfig)
+
+	assert.NoError(t, err)
+	assert.Empty(t, report)
+	assert.Empty(t, api.posted)
+}
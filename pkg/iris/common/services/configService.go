@@ -1,12 +1,14 @@
 package services
 
 import (
+	"context"
+
 	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
 )
 
 type ConfigService interface {
-	GetConfiguration(configurationName string) ([]byte, error)
-	GetScanResults(configurationId string) ([]models.DiscoveryEvent, error)
+	GetConfiguration(ctx context.Context, configurationName string) ([]byte, error)
+	GetScanResults(ctx context.Context, configurationId string) ([]models.DiscoveryEvent, error)
 }
 
 type configService struct {
@@ -19,10 +21,10 @@ func NewConfigService(irisApi IrisApi) ConfigService {
 	}
 }
 
-func (a *configService) GetConfiguration(configurationName string) ([]byte, error) {
-	return a.irisApi.GetConfiguration(configurationName)
+func (a *configService) GetConfiguration(ctx context.Context, configurationName string) ([]byte, error) {
+	return a.irisApi.GetConfiguration(ctx, configurationName)
 }
 
-func (a *configService) GetScanResults(configurationId string) ([]models.DiscoveryEvent, error) {
-	return a.irisApi.GetScanResults(configurationId)
+func (a *configService) GetScanResults(ctx context.Context, configurationId string) ([]models.DiscoveryEvent, error) {
+	return a.irisApi.GetScanResults(ctx, configurationId)
 }
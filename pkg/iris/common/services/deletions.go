@@ -0,0 +1,77 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+)
+
+// DeletionGuard stops a run from posting an unusual number of deleted events, e.g. because the
+// apiserver returned partial data or the connector lost access to the discovered resources
+type DeletionGuard struct {
+	// MaxDeletions is the maximum number of deleted events of a run, 0 means no limit
+	MaxDeletions int
+	// MaxDeletionPercent is the maximum share of the previously known items deleted in a run, 0
+	// means no limit
+	MaxDeletionPercent float64
+	// MinKnownItems is the number of previously known items below which MaxDeletionPercent does not
+	// apply, in a small inventory a few deletions are already a large share
+	MinKnownItems int
+	// AllowMassDeletion posts the deleted events regardless of the limits, for intentional cleanups
+	AllowMassDeletion bool
+}
+
+// DefaultDeletionGuard lets ordinary churn through but stops a run deleting half of the inventory
+var DefaultDeletionGuard = DeletionGuard{
+	MaxDeletions:       100,
+	MaxDeletionPercent: 50,
+	MinKnownItems:      10,
+}
+
+// MassDeletionError is returned instead of posting more deleted events than the DeletionGuard allows
+type MassDeletionError struct {
+	Deleted int
+	Known   int
+	Limit   string
+}
+
+func (e *MassDeletionError) Error() string {
+	return fmt.Sprintf("refusing to delete %d of %d previously known items, more than %s. The discovered data is likely incomplete, check the access of the connector to the cluster or allow mass deletion for an intentional cleanup", e.Deleted, e.Known, e.Limit)
+}
+
+// Check returns a MassDeletionError if deleting deleted of the known items exceeds a limit of the guard
+func (g DeletionGuard) Check(deleted int, known int) error {
+	if g.AllowMassDeletion || deleted == 0 {
+		return nil
+	}
+	if g.MaxDeletions > 0 && deleted > g.MaxDeletions {
+		return &MassDeletionError{Deleted: deleted, Known: known, Limit: fmt.Sprintf("the limit of %d items", g.MaxDeletions)}
+	}
+	if g.MaxDeletionPercent > 0 && known > 0 && known >= g.MinKnownItems && float64(deleted)*100 > g.MaxDeletionPercent*float64(known) {
+		return &MassDeletionError{Deleted: deleted, Known: known, Limit: fmt.Sprintf("the limit of %g%% of the known items", g.MaxDeletionPercent)}
+	}
+	return nil
+}
+
+// CheckClasses checks the deletion of the items of every class on its own. Known items of another
+// class are left over from a discovery mode turned off in the configuration, they are checked as
+// well, so a mistyped discovery mode does not delete a whole class without AllowMassDeletion.
+func (g DeletionGuard) CheckClasses(deleted []models.DiscoveryEvent, known []models.DiscoveryEvent) error {
+	for _, class := range []string{models.EventClassNamespace, models.EventClassWorkload} {
+		err := g.Check(countClass(deleted, class), countClass(known, class))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func countClass(events []models.DiscoveryEvent, class string) int {
+	count := 0
+	for _, event := range events {
+		if event.HeaderProperties.Class == class {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeletionGuardCheck(t *testing.T) {
+	guard := DeletionGuard{MaxDeletions: 10, MaxDeletionPercent: 50}
+
+	assert.NoError(t, guard.Check(0, 0))
+	assert.NoError(t, guard.Check(5, 10))
+	assert.NoError(t, guard.Check(10, 100))
+
+	err := guard.Check(11, 100)
+	var massDeletion *MassDeletionError
+	assert.ErrorAs(t, err, &massDeletion)
+	assert.Equal(t, MassDeletionError{Deleted: 11, Known: 100, Limit: "the limit of 10 items"}, *massDeletion)
+
+	err = guard.Check(6, 10)
+	assert.ErrorAs(t, err, &massDeletion)
+	assert.Equal(t, "the limit of 50% of the known items", massDeletion.Limit)
+	assert.Contains(t, err.Error(), "refusing to delete 6 of 10 previously known items")
+}
+
+func TestDeletionGuardCheck_allowMassDeletion(t *testing.T) {
+	guard := DeletionGuard{MaxDeletions: 10, MaxDeletionPercent: 50, AllowMassDeletion: true}
+
+	assert.NoError(t, guard.Check(100, 100))
+}
+
+func TestDeletionGuardCheck_noLimits(t *testing.T) {
+	assert.NoError(t, DeletionGuard{}.Check(100, 100))
+}
+
+func TestDeletionGuardCheck_minKnownItems(t *testing.T) {
+	guard := DeletionGuard{MaxDeletions: 10, MaxDeletionPercent: 50, MinKnownItems: 10}
+
+	// small inventories are only limited by the number of deletions
+	assert.NoError(t, guard.Check(1, 1))
+	assert.NoError(t, guard.Check(2, 3))
+	assert.NoError(t, guard.Check(9, 9))
+	assert.Error(t, guard.Check(6, 10))
+}
+
+func TestDeletionGuardCheckClasses(t *testing.T) {
+	guard := DeletionGuard{MaxDeletions: 10, MaxDeletionPercent: 50}
+	namespaces := make([]models.DiscoveryEvent, 4)
+	for i := range namespaces {
+		namespaces[i].HeaderProperties.Class = models.EventClassNamespace
+	}
+	workloads := make([]models.DiscoveryEvent, 4)
+	for i := range workloads {
+		workloads[i].HeaderProperties.Class = models.EventClassWorkload
+	}
+	known := append(append([]models.DiscoveryEvent{}, namespaces...), workloads...)
+
+	assert.NoError(t, guard.CheckClasses(append(namespaces[:2:2], workloads[:2]...), known))
+	err := guard.CheckClasses(workloads[:3], known)
+	var massDeletion *MassDeletionError
+	assert.ErrorAs(t, err, &massDeletion)
+	assert.Equal(t, MassDeletionError{Deleted: 3, Known: 4, Limit: "the limit of 50% of the known items"}, *massDeletion)
+
+	// the namespaces of a discovery mode turned off are deleted by the workload mode, but not all
+	// of them at once without an override
+	err = guard.CheckClasses(append(namespaces[:4:4], workloads[0]), known)
+	assert.ErrorAs(t, err, &massDeletion)
+	assert.Equal(t, MassDeletionError{Deleted: 4, Known: 4, Limit: "the limit of 50% of the known items"}, *massDeletion)
+	guard.AllowMassDeletion = true
+	assert.NoError(t, guard.CheckClasses(append(namespaces[:4:4], workloads[0]), known))
+}
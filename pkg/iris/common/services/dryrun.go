@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+)
+
+// DryRunConfig makes the scanner read from Iris as usual but only report what it would post
+type DryRunConfig struct {
+	Enabled bool
+	// Output receives the human-readable summary of the would-be events
+	Output io.Writer
+	// Payloads receives every would-be request body on a line of its own, nil skips the payloads
+	Payloads io.Writer
+}
+
+type dryRunIrisApi struct {
+	irisApi  IrisApi
+	output   io.Writer
+	payloads io.Writer
+
+	mu sync.Mutex
+}
+
+// NewDryRunIrisApi wraps irisApi so configurations and scan results are still read from Iris, but
+// ECST results, status and admin logs are written to the outputs of the config instead of posted
+func NewDryRunIrisApi(irisApi IrisApi, config DryRunConfig) IrisApi {
+	return &dryRunIrisApi{
+		irisApi:  irisApi,
+		output:   config.Output,
+		payloads: config.Payloads,
+	}
+}
+
+func (a *dryRunIrisApi) GetConfiguration(ctx context.Context, configurationName string) ([]byte, error) {
+	return a.irisApi.GetConfiguration(ctx, configurationName)
+}
+
+func (a *dryRunIrisApi) GetScanResults(ctx context.Context, configurationId string) ([]models.DiscoveryEvent, error) {
+	return a.irisApi.GetScanResults(ctx, configurationId)
+}
+
+func (a *dryRunIrisApi) PostEcstResults(_ context.Context, ecstResults []byte) error {
+	var events []models.DiscoveryEvent
+	err := json.Unmarshal(ecstResults, &events)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	err = a.writePayload(ecstResults)
+	if err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+	for _, event := range events {
+		counts[event.HeaderProperties.Action]++
+	}
+	fmt.Fprintf(a.output, "[dry-run] would post %d ECST events: %d created, %d updated, %d deleted\n",
+		len(events), counts[models.EventActionCreated], counts[models.EventActionUpdated], counts[models.EventActionDeleted])
+	for _, event := range events {
+		fmt.Fprintf(a.output, "  %s %s\n", event.HeaderProperties.Action, Describe(event))
+		for _, change := range event.Body.Changes {
+			fmt.Fprintf(a.output, "    %s\n", change)
+		}
+	}
+	return nil
+}
+
+func (a *dryRunIrisApi) PostStatus(_ context.Context, status []byte) error {
+	var items []models.StatusItem
+	err := json.Unmarshal(status, &items)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, item := range items {
+		message := ""
+		if data, ok := item.Data.(map[string]interface{}); ok {
+			message = fmt.Sprint(data["message"])
+		}
+		fmt.Fprintf(a.output, "[dry-run] would post %s %s: %s\n", item.Type, item.Subject, message)
+	}
+	return nil
+}
+
+func (a *dryRunIrisApi) writePayload(ecstResults []byte) error {
+	if a.payloads == nil {
+		return nil
+	}
+	_, err := fmt.Fprintf(a.payloads, "%s\n", ecstResults)
+	return err
+}
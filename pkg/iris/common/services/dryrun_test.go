@@ -0,0 +1,90 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/diff"
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// readOnlyIrisApi serves scan results and fails the test on every post
+type readOnlyIrisApi struct {
+	t       *testing.T
+	results []models.DiscoveryEvent
+}
+
+func (a *readOnlyIrisApi) GetConfiguration(context.Context, string) ([]byte, error) {
+	return []byte(`{}`), nil
+}
+
+func (a *readOnlyIrisApi) GetScanResults(context.Context, string) ([]models.DiscoveryEvent, error) {
+	return a.results, nil
+}
+
+func (a *readOnlyIrisApi) PostEcstResults(context.Context, []byte) error {
+	a.t.Error("dry run posted ECST results")
+	return nil
+}
+
+func (a *readOnlyIrisApi) PostStatus(context.Context, []byte) error {
+	a.t.Error("dry run posted a status")
+	return nil
+}
+
+func testWorkload(name string, image string) workload.Data {
+	return workload.Data{
+		Workload: workload.Workload{
+			Name:         name,
+			WorkloadType: "deployment",
+			WorkloadProperties: workload.WorkloadProperties{
+				Replicas:   "1",
+				Containers: workload.Containers{{Name: "app", Image: image, Role: "main"}},
+			},
+		},
+		Cluster:       workload.Cluster{Name: "prod"},
+		NamespaceName: "web",
+	}
+}
+
+func TestDryRunIrisApi(t *testing.T) {
+	known := workload.CreateEcstDiscoveryEvent(models.EVENT_TYPE_STATE, "", "shop-id", testWorkload("shop", "shop:1.0"), "runId", "workspaceId", "configId")
+	gone := workload.CreateEcstDiscoveryEvent(models.EVENT_TYPE_STATE, "", "cart-id", testWorkload("cart", "cart:1.0"), "runId", "workspaceId", "configId")
+	// results read from Iris carry generic maps as data
+	var results []models.DiscoveryEvent
+	marshalled, err := json.Marshal([]models.DiscoveryEvent{known, gone})
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(marshalled, &results))
+	output := &bytes.Buffer{}
+	payloads := &bytes.Buffer{}
+	api := NewDryRunIrisApi(&readOnlyIrisApi{t: t, results: results}, DryRunConfig{Enabled: true, Output: output, Payloads: payloads})
+
+	oldResults, err := api.GetScanResults(context.Background(), "configId")
+	assert.NoError(t, err)
+	assert.Len(t, oldResults, 2)
+	events := []models.DiscoveryEvent{
+		workload.CreateEcstDiscoveryEvent(models.EventTypeChange, models.EventActionCreated, "search-id", testWorkload("search", "search:1.0"), "runId", "workspaceId", "configId"),
+		workload.CreateEcstDiscoveryEvent(models.EventTypeChange, models.EventActionUpdated, "shop-id", testWorkload("shop", "shop:1.1"), "runId", "workspaceId", "configId"),
+		workload.CreateEcstDiscoveryEvent(models.EventTypeChange, models.EventActionDeleted, "cart-id", testWorkload("cart", "cart:1.0"), "runId", "workspaceId", "configId"),
+	}
+	events[1].Body.Changes = []diff.Change{{Path: "workload.workloadProperties.containers[0].image", Kind: diff.KindChanged, Old: "shop:1.0", New: "shop:1.1"}}
+	body, err := json.Marshal(events)
+	assert.NoError(t, err)
+	err = api.PostEcstResults(context.Background(), body)
+	assert.NoError(t, err)
+	err = api.PostStatus(context.Background(), []byte(`[{"type": "leanix.vsm.item-logged.status", "subject": "SUCCESSFUL", "data": {"status": "SUCCESSFUL", "message": "Successfully Scanned"}}]`))
+	assert.NoError(t, err)
+
+	assert.Equal(t, `[dry-run] would post 3 ECST events: 1 created, 1 updated, 1 deleted
+  created deployment/search in namespace web of cluster/prod
+  updated deployment/shop in namespace web of cluster/prod
+    workload.workloadProperties.containers[0].image: "shop:1.0" -> "shop:1.1"
+  deleted deployment/cart in namespace web of cluster/prod
+[dry-run] would post leanix.vsm.item-logged.status SUCCESSFUL: Successfully Scanned
+`, output.String())
+	assert.Equal(t, string(body)+"\n", payloads.String())
+}
@@ -2,6 +2,9 @@ package services
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
+
 	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
 	namespace "github.com/leanix/leanix-k8s-connector/pkg/iris/namespaces/models"
 	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
@@ -54,3 +57,38 @@ func ParseWorkloadData(oldItem models.DiscoveryEvent) (*workload.Data, error) {
 	}
 	return &mappedData, nil
 }
+
+// MaxSummarizedUpdates caps the number of updated items listed in a change summary
+const MaxSummarizedUpdates = 20
+
+// SummarizeUpdates lists the changed fields of the updated items, one line per item, e.g.
+// deployment/foo in namespace web of cluster/prod: workload.workloadProperties.containers[0].imageTag: "1.2" -> "1.3"
+func SummarizeUpdates(updated []models.DiscoveryEvent) string {
+	lines := make([]string, 0, min(len(updated), MaxSummarizedUpdates)+2)
+	lines = append(lines, fmt.Sprintf("Updated %d items:", len(updated)))
+	for i, event := range updated {
+		if i == MaxSummarizedUpdates {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(updated)-MaxSummarizedUpdates))
+			break
+		}
+		changes := make([]string, 0, len(event.Body.Changes))
+		for _, change := range event.Body.Changes {
+			changes = append(changes, change.String())
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", Describe(event), strings.Join(changes, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Describe names the item of an event, e.g. deployment/shop in namespace web of cluster/prod
+func Describe(event models.DiscoveryEvent) string {
+	description := event.Body.State.Name
+	if event.HeaderProperties.Class == models.EventClassWorkload {
+		if data, err := ParseWorkloadData(event); err == nil && data.NamespaceName != "" {
+			description = fmt.Sprintf("%s in namespace %s", description, data.NamespaceName)
+		}
+	} else if event.HeaderProperties.Class == models.EventClassNamespace {
+		description = "namespace " + description
+	}
+	return fmt.Sprintf("%s of %s", description, event.Body.State.SourceInstance)
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/diff"
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	namespace "github.com/leanix/leanix-k8s-connector/pkg/iris/namespaces/models"
+	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeUpdates(t *testing.T) {
+	shop := workload.CreateEcstDiscoveryEvent(models.EventTypeChange, models.EventActionUpdated, "shop-id", testWorkload("shop", "shop:1.3"), "runId", "workspaceId", "configId")
+	shop.Body.Changes = []diff.Change{
+		{Path: "workload.workloadProperties.containers[0].imageTag", Kind: diff.KindChanged, Old: "1.2", New: "1.3"},
+		{Path: "workload.labels.tier", Kind: diff.KindAdded, New: "web"},
+	}
+	web := namespace.CreateEcstDiscoveryEvent(models.EventTypeChange, models.EventActionUpdated, "web-id", namespace.Data{Cluster: namespace.ClusterEcst{Name: "prod", Namespace: "web"}}, "workspaceId", "configId")
+	web.Body.Changes = []diff.Change{{Path: "cluster.k8sVersion", Kind: diff.KindChanged, Old: "1.29", New: "1.30"}}
+
+	summary := SummarizeUpdates([]models.DiscoveryEvent{shop, web})
+
+	assert.Equal(t, `Updated 2 items:
+deployment/shop in namespace web of cluster/prod: workload.workloadProperties.containers[0].imageTag: "1.2" -> "1.3", workload.labels.tier: added "web"
+namespace web of cluster/prod: cluster.k8sVersion: "1.29" -> "1.30"`, summary)
+}
+
+func TestSummarizeUpdates_capped(t *testing.T) {
+	updated := make([]models.DiscoveryEvent, 0)
+	for i := 0; i < MaxSummarizedUpdates+5; i++ {
+		updated = append(updated, workload.CreateEcstDiscoveryEvent(models.EventTypeChange, models.EventActionUpdated, "id", testWorkload(fmt.Sprintf("shop-%d", i), "shop:1.0"), "runId", "workspaceId", "configId"))
+	}
+
+	lines := strings.Split(SummarizeUpdates(updated), "\n")
+
+	assert.Len(t, lines, MaxSummarizedUpdates+2)
+	assert.Equal(t, "... and 5 more", lines[len(lines)-1])
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how often and how long the Iris client retries failed requests
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first one, values below 2 disable retries
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, it doubles with every further retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts, including delays requested by Retry-After
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy rides out a gateway restart without delaying a failing run for long
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 1 * time.Second,
+	MaxBackoff:     30 * time.Second,
+}
+
+// retryCondition tells whether a failed attempt may be sent again
+type retryCondition func(resp *http.Response, err error) bool
+
+// retryIdempotent retries network errors and responses of overloaded or restarting servers. It
+// is used for GET requests and for posting ECST events, which Iris applies idempotently.
+func retryIdempotent(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryRejected only retries responses that guarantee the request was not processed. It is used
+// for status and admin log events, which would show up twice if they were posted again.
+func retryRejected(resp *http.Response, err error) bool {
+	if err != nil {
+		return false
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// delay returns how long to wait before the given retry, counting from 1. The exponential backoff
+// is jittered by up to half of it, so connectors of many clusters do not retry in lockstep. A
+// Retry-After header of a 429 or 503 response takes precedence.
+func (p RetryPolicy) delay(retry int, resp *http.Response) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < retry && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > 0 {
+		backoff = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+	}
+	if retryAfter, ok := retryAfter(resp); ok {
+		backoff = retryAfter
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	return backoff
+}
+
+// retryAfter parses the Retry-After header of 429 and 503 responses, given in seconds or as date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	for retry, backoff := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second} {
+		delay := policy.delay(retry, nil)
+		assert.GreaterOrEqual(t, delay, backoff/2)
+		assert.LessOrEqual(t, delay, backoff)
+	}
+	assert.LessOrEqual(t, policy.delay(10, nil), 5*time.Second)
+}
+
+func TestRetryPolicyDelay_retryAfter(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: time.Minute}
+	response := func(statusCode int, retryAfter string) *http.Response {
+		resp := &http.Response{StatusCode: statusCode, Header: http.Header{}}
+		resp.Header.Set("Retry-After", retryAfter)
+		return resp
+	}
+
+	assert.Equal(t, 7*time.Second, policy.delay(1, response(http.StatusTooManyRequests, "7")))
+	assert.Equal(t, 7*time.Second, policy.delay(1, response(http.StatusServiceUnavailable, "7")))
+	assert.Equal(t, time.Minute, policy.delay(1, response(http.StatusServiceUnavailable, "3600")))
+	date := time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat)
+	assert.InDelta(t, float64(30*time.Second), float64(policy.delay(1, response(http.StatusServiceUnavailable, date))), float64(2*time.Second))
+	// Retry-After is only meaningful for 429 and 503
+	assert.LessOrEqual(t, policy.delay(1, response(http.StatusBadGateway, "7")), time.Second)
+}
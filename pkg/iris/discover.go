@@ -0,0 +1,59 @@
+package iris
+
+import (
+	"context"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	namespaceModels "github.com/leanix/leanix-k8s-connector/pkg/iris/namespaces/models"
+	namespaceMap "github.com/leanix/leanix-k8s-connector/pkg/iris/namespaces/services/mapper"
+	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+	workloadMap "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/services/mapper"
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+)
+
+// Discovery holds the items discovered in the enabled discovery modes of a configuration
+type Discovery struct {
+	Namespaces []namespaceModels.Data
+	Workloads  []workload.Data
+}
+
+// Discover maps the cluster with the configuration like a scan, but without reading from or
+// posting to Iris, e.g. for an offline export
+func Discover(ctx context.Context, kubernetesAPI *kubernetes.API, kubernetesConfig models.KubernetesConfig) (Discovery, error) {
+	// the mapping of the scanner does not need Iris
+	s := &scanner{}
+	kubernetesAPI = kubernetesAPI.WithSnapshot()
+	var discovery Discovery
+	nodes, err := kubernetesAPI.Nodes(ctx)
+	if err != nil {
+		return discovery, err
+	}
+
+	if kubernetesConfig.DiscoveryMode.Namespaces() {
+		mapper := namespaceMap.NewMapper(kubernetesAPI, kubernetesConfig.Cluster, "", kubernetesConfig.BlackListedNamespaces, "")
+		clusterDTO, err := mapper.MapCluster(kubernetesConfig.Cluster, nodes)
+		if err != nil {
+			return discovery, err
+		}
+		namespaces, err := kubernetesAPI.Namespaces(ctx, kubernetesConfig.BlackListedNamespaces)
+		if err != nil {
+			return discovery, err
+		}
+		discovery.Namespaces, err = s.ProcessNamespace(ctx, kubernetesAPI, mapper, namespaces.Items, clusterDTO)
+		if err != nil {
+			return discovery, err
+		}
+	}
+	if kubernetesConfig.DiscoveryMode.Workloads() {
+		mapper := workloadMap.NewMapper(kubernetesAPI, kubernetesConfig.Cluster, "", "", kubernetesConfig.CustomWorkloads)
+		clusterInfo, err := mapper.MapCluster(kubernetesConfig.Cluster, nodes)
+		if err != nil {
+			return discovery, err
+		}
+		discovery.Workloads, err = s.ProcessWorkloads(ctx, mapper, clusterInfo)
+		if err != nil {
+			return discovery, err
+		}
+	}
+	return discovery, nil
+}
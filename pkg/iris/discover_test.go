@@ -0,0 +1,52 @@
+package iris
+
+import (
+	"context"
+	"testing"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDiscover(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "web"}},
+	)
+	config := models.KubernetesConfig{
+		Cluster:               "prod",
+		BlackListedNamespaces: []string{"kube-system"},
+		DiscoveryMode:         models.DiscoveryModes{models.DiscoveryModeNamespace, models.DiscoveryModeWorkload},
+	}
+
+	discovery, err := Discover(context.Background(), &kubernetes.API{Client: client}, config)
+
+	assert.NoError(t, err)
+	assert.Len(t, discovery.Namespaces, 1)
+	assert.Equal(t, "web", discovery.Namespaces[0].Cluster.Namespace)
+	assert.Equal(t, "prod", discovery.Namespaces[0].Cluster.Name)
+	assert.Len(t, discovery.Workloads, 1)
+	assert.Equal(t, "shop", discovery.Workloads[0].Workload.Name)
+	assert.Equal(t, "prod", discovery.Workloads[0].Cluster.Name)
+}
+
+func TestDiscover_workloadsOnly(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "web"}},
+	)
+	config := models.KubernetesConfig{Cluster: "prod", DiscoveryMode: models.DiscoveryModes{models.DiscoveryModeWorkload}}
+
+	discovery, err := Discover(context.Background(), &kubernetes.API{Client: client}, config)
+
+	assert.NoError(t, err)
+	assert.Empty(t, discovery.Namespaces)
+	assert.Len(t, discovery.Workloads, 1)
+}
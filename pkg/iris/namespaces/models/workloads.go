@@ -20,6 +20,20 @@ type DeploymentEcst struct {
 	Labels               interface{}          `json:"labels"`
 	Timestamp            string               `json:"time"`
 	DeploymentProperties DeploymentProperties `json:"deploymentProperties"`
+	Containers           []Container          `json:"containers"`
+}
+
+type Container struct {
+	Name            string       `json:"name"`
+	Image           string       `json:"image"`
+	ImageRegistry   string       `json:"imageRegistry"`
+	ImageRepository string       `json:"imageRepository"`
+	ImageTag        string       `json:"imageTag"`
+	ImageDigest     string       `json:"imageDigest"`
+	Role            string       `json:"role"`
+	Port            interface{}  `json:"port"`
+	K8sLimits       K8sResources `json:"k8sLimits"`
+	K8sRequests     K8sResources `json:"k8sRequests"`
 }
 
 type DeploymentProperties struct {
@@ -1,62 +1,80 @@
 package events
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"github.com/leanix/leanix-k8s-connector/pkg/diff"
 	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
 	common "github.com/leanix/leanix-k8s-connector/pkg/iris/common/services"
 	namespace "github.com/leanix/leanix-k8s-connector/pkg/iris/namespaces/models"
-	"github.com/pkg/errors"
+	"github.com/leanix/leanix-k8s-connector/pkg/metrics"
+	"github.com/leanix/leanix-k8s-connector/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
 )
 
 type EventProducer interface {
-	ProcessResults(data []namespace.Data, oldData []models.DiscoveryEvent, configId string) error
-	PostStatus(status []byte) error
-	FilterForChangedItems(newData map[string]namespace.Data, oldData map[string]models.DiscoveryEvent, configId string) ([]models.DiscoveryEvent, []models.DiscoveryEvent, map[string]models.DiscoveryEvent, error)
+	ProcessResults(ctx context.Context, data []namespace.Data, oldData []models.DiscoveryEvent, configId string) (common.ProcessReport, error)
+	PostStatus(ctx context.Context, status []byte) error
+	FilterForChangedItems(ctx context.Context, newData map[string]namespace.Data, oldData map[string]models.DiscoveryEvent, configId string) ([]models.DiscoveryEvent, []models.DiscoveryEvent, map[string]models.DiscoveryEvent, error)
 }
 
 type eventProducer struct {
-	irisApi     common.IrisApi
-	runId       string
-	workspaceId string
+	irisApi       common.IrisApi
+	runId         string
+	workspaceId   string
+	batchConfig   common.BatchConfig
+	deletionGuard common.DeletionGuard
 }
 
-func NewEventProducer(irisApi common.IrisApi, runId string, workspaceId string) EventProducer {
+func NewEventProducer(irisApi common.IrisApi, runId string, workspaceId string, batchConfig common.BatchConfig, deletionGuard common.DeletionGuard) EventProducer {
 	return &eventProducer{
-		irisApi:     irisApi,
-		runId:       runId,
-		workspaceId: workspaceId,
+		irisApi:       irisApi,
+		runId:         runId,
+		workspaceId:   workspaceId,
+		batchConfig:   batchConfig,
+		deletionGuard: deletionGuard,
 	}
 }
 
-func (p *eventProducer) ProcessResults(data []namespace.Data, oldData []models.DiscoveryEvent, configId string) error {
-	created, updated, deleted, err := p.createECSTEvents(data, oldData, configId)
+// ProcessResults posts the created, updated and deleted events in batches, see common.PostEcstBatches.
+// Nothing is posted if the deleted events exceed the limits of the deletion guard.
+func (p *eventProducer) ProcessResults(ctx context.Context, data []namespace.Data, oldData []models.DiscoveryEvent, configId string) (common.ProcessReport, error) {
+	started := time.Now()
+	created, updated, deleted, err := p.createECSTEvents(ctx, data, oldData, configId)
+	metrics.ObservePhase(metrics.PhaseDiffing, time.Since(started))
 	if err != nil {
-		return err
+		return common.ProcessReport{}, err
+	}
+	err = p.deletionGuard.CheckClasses(deleted, oldData)
+	if err != nil {
+		return common.ProcessReport{}, err
 	}
 	ecstEvents := append(created, updated...)
 	ecstEvents = append(ecstEvents, deleted...)
 	if len(ecstEvents) == 0 {
-		return nil
+		return common.ProcessReport{Batches: common.BatchReport{}, Updated: updated, Changes: ecstEvents}, nil
 	}
-	scannedEcstObjectsByte, err := json.Marshal(ecstEvents)
+	started = time.Now()
+	batches, err := common.PostEcstBatches(ctx, p.irisApi, ecstEvents, p.batchConfig)
+	metrics.ObservePhase(metrics.PhasePosting, time.Since(started))
 	if err != nil {
-		return errors.Wrap(err, "Marshall scanned ECST services")
+		return common.ProcessReport{}, err
 	}
-
-	return p.irisApi.PostEcstResults(scannedEcstObjectsByte)
+	return common.ProcessReport{Batches: batches, Updated: updated, Changes: ecstEvents}, nil
 }
 
-func (p *eventProducer) PostStatus(status []byte) error {
-	return p.irisApi.PostStatus(status)
+func (p *eventProducer) PostStatus(ctx context.Context, status []byte) error {
+	return p.irisApi.PostStatus(ctx, status)
 }
 
-func (p *eventProducer) createECSTEvents(data []namespace.Data, oldData []models.DiscoveryEvent, configId string) ([]models.DiscoveryEvent, []models.DiscoveryEvent, []models.DiscoveryEvent, error) {
+func (p *eventProducer) createECSTEvents(ctx context.Context, data []namespace.Data, oldData []models.DiscoveryEvent, configId string) ([]models.DiscoveryEvent, []models.DiscoveryEvent, []models.DiscoveryEvent, error) {
 	resultMap := p.createItemMap(data, configId)
 	oldResultMap := p.createOldItemMap(oldData)
 
-	createdEvents, updatedEvents, oldResultMap, err := p.FilterForChangedItems(resultMap, oldResultMap, configId)
+	createdEvents, updatedEvents, oldResultMap, err := p.FilterForChangedItems(ctx, resultMap, oldResultMap, configId)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -99,7 +117,9 @@ func GenerateHash(toBeHashed interface{}) (string, error) {
 
 }
 
-func (p *eventProducer) FilterForChangedItems(newData map[string]namespace.Data, oldData map[string]models.DiscoveryEvent, configId string) ([]models.DiscoveryEvent, []models.DiscoveryEvent, map[string]models.DiscoveryEvent, error) {
+func (p *eventProducer) FilterForChangedItems(ctx context.Context, newData map[string]namespace.Data, oldData map[string]models.DiscoveryEvent, configId string) ([]models.DiscoveryEvent, []models.DiscoveryEvent, map[string]models.DiscoveryEvent, error) {
+	_, span := tracing.Start(ctx, "FilterForChangedItems", attribute.Int("items.new", len(newData)), attribute.Int("items.old", len(oldData)))
+	defer span.End()
 	updated := make([]models.DiscoveryEvent, 0)
 	created := make([]models.DiscoveryEvent, 0)
 	for id, newItem := range newData {
@@ -109,7 +129,13 @@ func (p *eventProducer) FilterForChangedItems(newData map[string]namespace.Data,
 			created = append(created, createdEcstDiscoveryEvent)
 			// if item has been discovered before, check if there are any changes in the new payload
 		} else {
-			oldItemHash, err := GenerateHash(oldItem.Body.State.Data)
+			// Parse the old payload into the current model first, so results posted with an older
+			// shape of the model are compared field by field instead of by their raw JSON
+			oldItemData, err := common.ParseNamespaceData(oldItem)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			oldItemHash, err := GenerateHash(oldItemData)
 			if err != nil {
 				return nil, nil, nil, err
 			}
@@ -119,7 +145,13 @@ func (p *eventProducer) FilterForChangedItems(newData map[string]namespace.Data,
 			}
 
 			if oldItemHash != newItemHash {
-				updated = append(updated, namespace.CreateEcstDiscoveryEvent(models.EventTypeChange, models.EventActionUpdated, id, newItem, p.workspaceId, configId))
+				changes, err := diff.Compare(oldItemData, newItem)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				updatedEvent := namespace.CreateEcstDiscoveryEvent(models.EventTypeChange, models.EventActionUpdated, id, newItem, p.workspaceId, configId)
+				updatedEvent.Body.Changes = changes
+				updated = append(updated, updatedEvent)
 			}
 			// Remove key from oldData results, so we only have the entries inside which shall be deleted
 			delete(oldData, id)
@@ -1,6 +1,7 @@
 package events
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -40,8 +41,8 @@ func Test_eventProducer_filter_created(t *testing.T) {
 		},
 	}
 	//oldData map[string]models.DiscoveryEvent
-	p := NewEventProducer(mockApi, "testRunId", "testWorkspaceId")
-	created, updated, _, err := p.FilterForChangedItems(newData, oldData, "testConfigId")
+	p := NewEventProducer(mockApi, "testRunId", "testWorkspaceId", common.DefaultBatchConfig, common.DefaultDeletionGuard)
+	created, updated, _, err := p.FilterForChangedItems(context.Background(), newData, oldData, "testConfigId")
 
 	assert.NoError(t, err)
 	assert.Empty(t, updated)
@@ -86,8 +87,8 @@ func Test_eventProducer_filter_updated_no_change(t *testing.T) {
 		},
 	}
 	//oldData map[string]models.DiscoveryEvent
-	p := NewEventProducer(mockApi, "testRunId", "testWorkspaceId")
-	created, updated, filteredData, err := p.FilterForChangedItems(newData, oldData, "testConfigId")
+	p := NewEventProducer(mockApi, "testRunId", "testWorkspaceId", common.DefaultBatchConfig, common.DefaultDeletionGuard)
+	created, updated, filteredData, err := p.FilterForChangedItems(context.Background(), newData, oldData, "testConfigId")
 
 	assert.NoError(t, err)
 	assert.Empty(t, updated)
@@ -137,7 +138,7 @@ func Test_eventProducer_filter_updated_changed(t *testing.T) {
 		runId:       "testRunId",
 		workspaceId: "testWorkspaceId",
 	}
-	created, updated, filteredData, err := p.FilterForChangedItems(newData, oldData, "testConfigId")
+	created, updated, filteredData, err := p.FilterForChangedItems(context.Background(), newData, oldData, "testConfigId")
 
 	assert.NoError(t, err)
 	parsedData, err := common.ParseNamespaceData(updated[0])
@@ -265,7 +266,7 @@ func Test_eventProducer_createECSTEvents(t *testing.T) {
 		runId:       "testRunId",
 		workspaceId: "testWorkspaceId",
 	}
-	created, updated, deleted, err := p.createECSTEvents(newData, oldData, "testConfigId")
+	created, updated, deleted, err := p.createECSTEvents(context.Background(), newData, oldData, "testConfigId")
 
 	assert.NoError(t, err)
 	assert.Len(t, created, 1)
@@ -298,8 +299,10 @@ func Test_eventProducer_processECSTResults_empty(t *testing.T) {
 
 	var newData []namespaceModels.Data
 	var oldData []models.DiscoveryEvent
-	p := NewEventProducer(mockApi, "testRunId", "testWorkspaceId")
-	err := p.ProcessResults(newData, oldData, "testConfigId")
+	p := NewEventProducer(mockApi, "testRunId", "testWorkspaceId", common.DefaultBatchConfig, common.DefaultDeletionGuard)
+	report, err := p.ProcessResults(context.Background(), newData, oldData, "testConfigId")
 
 	assert.NoError(t, err)
+	assert.Empty(t, report.Batches)
+	assert.Empty(t, report.Updated)
 }
@@ -2,7 +2,7 @@ package mapper
 
 import (
 	"github.com/leanix/leanix-k8s-connector/pkg/iris/namespaces/models"
-	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -11,6 +11,7 @@ import (
 	"github.com/leanix/leanix-k8s-connector/pkg/set"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 type Mapper interface {
@@ -79,28 +80,34 @@ func (m *mapper) MapDeploymentsEcst(deployments *appsv1.DeploymentList, services
 	var allDeployments []models.DeploymentEcst
 
 	for _, deployment := range deployments.Items {
+		// the service of the deployment is the first one selecting its pods
 		deploymentService := ""
-		// Check if any service has the exact same selector labels and use this as the service related to the deployment
-		deploymentService = ResolveK8sServiceForK8sDeployment(services, deployment)
+		if deploymentServices := kubernetes.SelectingServices(services, deployment.Namespace, deployment.Spec.Template); len(deploymentServices) > 0 {
+			deploymentService = deploymentServices[0].Name
+		}
 		allDeployments = append(allDeployments, m.CreateDeploymentEcst(deploymentService, deployment))
 	}
+	sort.SliceStable(allDeployments, func(i, j int) bool {
+		return allDeployments[i].DeploymentName < allDeployments[j].DeploymentName
+	})
 
 	return allDeployments, nil
 }
 
 func (m *mapper) CreateDeployment(deploymentService string, deployment appsv1.Deployment) models.Deployment {
-
+	// the deployment level image and resources are the ones of the main container
+	mainContainer, _ := kubernetes.MainContainer(kubernetes.PodContainers(deployment.Spec.Template))
 	mappedDeployment := models.Deployment{
 		Service:        &models.Service{Name: deploymentService},
-		Image:          deployment.Spec.Template.Spec.Containers[0].Image,
+		Image:          mainContainer.Image,
 		DeploymentName: deployment.Name,
 		Labels:         deployment.ObjectMeta.Labels,
 		Timestamp:      deployment.CreationTimestamp.UTC().Format(time.RFC3339),
 		Properties: models.DeploymentProperties{
 			UpdateStrategy: string(deployment.Spec.Strategy.Type),
 			Replicas:       strconv.FormatInt(int64(deployment.Status.Replicas), 10),
-			K8sLimits:      CreateK8sResources(deployment.Spec.Template.Spec.Containers[0].Resources.Limits),
-			K8sRequests:    CreateK8sResources(deployment.Spec.Template.Spec.Containers[0].Resources.Requests),
+			K8sLimits:      CreateK8sResources(mainContainer.Resources.Limits),
+			K8sRequests:    CreateK8sResources(mainContainer.Resources.Requests),
 		},
 	}
 	return mappedDeployment
@@ -111,33 +118,59 @@ func (m *mapper) CreateDeploymentEcst(deploymentService string, deployment appsv
 	if deploymentService != "" {
 		service = deploymentService
 	}
+	podContainers := kubernetes.PodContainers(deployment.Spec.Template)
+	// the deployment level image and resources are the ones of the main container
+	mainContainer, _ := kubernetes.MainContainer(podContainers)
 	mappedDeployment := models.DeploymentEcst{
 		ServiceName:    service,
-		Image:          deployment.Spec.Template.Spec.Containers[0].Image,
+		Image:          mainContainer.Image,
 		DeploymentName: deployment.Name,
 		Labels:         deployment.ObjectMeta.Labels,
 		Timestamp:      deployment.CreationTimestamp.UTC().Format(time.RFC3339),
 		DeploymentProperties: models.DeploymentProperties{
 			UpdateStrategy: string(deployment.Spec.Strategy.Type),
 			Replicas:       strconv.FormatInt(int64(deployment.Status.Replicas), 10),
-			K8sLimits:      CreateK8sResources(deployment.Spec.Template.Spec.Containers[0].Resources.Limits),
-			K8sRequests:    CreateK8sResources(deployment.Spec.Template.Spec.Containers[0].Resources.Requests),
+			K8sLimits:      CreateK8sResources(mainContainer.Resources.Limits),
+			K8sRequests:    CreateK8sResources(mainContainer.Resources.Requests),
 		},
+		Containers: MapContainers(podContainers),
 	}
 	return mappedDeployment
 }
 
+// MapContainers maps every container of the deployment in canonical order, see
+// kubernetes.PodContainers for the roles
+func MapContainers(podContainers []kubernetes.PodContainer) []models.Container {
+	containers := make([]models.Container, 0, len(podContainers))
+	for _, c := range kubernetes.CanonicalOrder(podContainers) {
+		containers = append(containers, models.Container{
+			Name:            c.Name,
+			Image:           c.Image,
+			ImageRegistry:   c.ImageReference.Registry,
+			ImageRepository: c.ImageReference.Repository,
+			ImageTag:        c.ImageReference.Tag,
+			ImageDigest:     c.ImageReference.Digest,
+			Role:            c.Role,
+			Port:            c.Ports,
+			K8sLimits:       CreateK8sResources(c.Resources.Limits),
+			K8sRequests:     CreateK8sResources(c.Resources.Requests),
+		})
+	}
+	return containers
+}
+
+// CreateK8sResources maps cpu and memory in canonical form, e.g. 1000m cpu and 1 cpu both map to 1
 func CreateK8sResources(resourceList v1.ResourceList) models.K8sResources {
 	cpu := resourceList[v1.ResourceCPU]
 	cpuString := ""
 	if !cpu.IsZero() {
-		cpuString = cpu.String()
+		cpuString = resource.NewMilliQuantity(cpu.MilliValue(), cpu.Format).String()
 	}
 
 	memory := resourceList[v1.ResourceMemory]
 	memoryString := ""
 	if !memory.IsZero() {
-		memoryString = memory.String()
+		memoryString = resource.NewQuantity(memory.Value(), memory.Format).String()
 	}
 
 	return models.K8sResources{
@@ -145,23 +178,3 @@ func CreateK8sResources(resourceList v1.ResourceList) models.K8sResources {
 		Memory: memoryString,
 	}
 }
-
-func ResolveK8sServiceForK8sDeployment(services *v1.ServiceList, deployment appsv1.Deployment) string {
-	deploymentService := ""
-	for _, service := range services.Items {
-		sharedLabelsDeployment := map[string]string{}
-		sharedLabelsService := map[string]string{}
-		for label := range service.Spec.Selector {
-			if _, ok := deployment.Spec.Selector.MatchLabels[label]; ok {
-				sharedLabelsDeployment[label] = deployment.Spec.Selector.MatchLabels[label]
-				sharedLabelsService[label] = service.Spec.Selector[label]
-			}
-		}
-
-		if len(sharedLabelsDeployment) != 0 && len(sharedLabelsService) != 0 && reflect.DeepEqual(sharedLabelsDeployment, sharedLabelsService) {
-			deploymentService = service.Name
-			break
-		}
-	}
-	return deploymentService
-}
@@ -39,6 +39,12 @@ func TestMapDeployments(t *testing.T) {
 						},
 					},
 					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								"app":  "app2",
+								"test": "false",
+							},
+						},
 						Spec: corev1.PodSpec{
 							Containers: []corev1.Container{
 								{Image: "testImage",
@@ -156,6 +162,12 @@ func TestMapDeployments_NoService(t *testing.T) {
 						},
 					},
 					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								"app":  "app2",
+								"test": "false",
+							},
+						},
 						Spec: corev1.PodSpec{
 							Containers: []corev1.Container{
 								{Image: "testImage",
@@ -365,6 +377,12 @@ func TestResolveServiceForDeployment_Success(t *testing.T) {
 				},
 			},
 			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app":  "app1",
+						"test": "false",
+					},
+				},
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{
 						{Resources: corev1.ResourceRequirements{
@@ -381,9 +399,10 @@ func TestResolveServiceForDeployment_Success(t *testing.T) {
 			ReadyReplicas: 1,
 		},
 	}
-	result := ResolveK8sServiceForK8sDeployment(&dummyServices, dummyDeployment)
-	assert.NotEmpty(t, result)
-	assert.Equal(t, "test-service-1", result)
+	result, err := (&mapper{}).MapDeploymentsEcst(&appsv1.DeploymentList{Items: []appsv1.Deployment{dummyDeployment}}, &dummyServices)
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, "test-service-1", result[0].ServiceName)
 }
 
 func TestResolveServiceForDeployment_NoCommonLabels(t *testing.T) {
@@ -465,6 +484,12 @@ func TestResolveServiceForDeployment_NoCommonLabels(t *testing.T) {
 				},
 			},
 			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"production": "ready",
+						"test":       "false",
+					},
+				},
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{
 						{Resources: corev1.ResourceRequirements{
@@ -481,8 +506,9 @@ func TestResolveServiceForDeployment_NoCommonLabels(t *testing.T) {
 			ReadyReplicas: 1,
 		},
 	}
-	result := ResolveK8sServiceForK8sDeployment(&dummyServices, dummyDeployment)
-	assert.Equal(t, "", result)
+	result, err := (&mapper{}).MapDeploymentsEcst(&appsv1.DeploymentList{Items: []appsv1.Deployment{dummyDeployment}}, &dummyServices)
+	assert.NoError(t, err)
+	assert.Equal(t, "", result[0].ServiceName)
 }
 
 func TestResolveServiceForDeployment_DifferentLabelValues(t *testing.T) {
@@ -565,6 +591,12 @@ func TestResolveServiceForDeployment_DifferentLabelValues(t *testing.T) {
 				},
 			},
 			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app":  "app3",
+						"test": "false",
+					},
+				},
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{
 						{Resources: corev1.ResourceRequirements{
@@ -581,6 +613,7 @@ func TestResolveServiceForDeployment_DifferentLabelValues(t *testing.T) {
 			ReadyReplicas: 1,
 		},
 	}
-	result := ResolveK8sServiceForK8sDeployment(&dummyServices, dummyDeployment)
-	assert.Equal(t, "", result)
+	result, err := (&mapper{}).MapDeploymentsEcst(&appsv1.DeploymentList{Items: []appsv1.Deployment{dummyDeployment}}, &dummyServices)
+	assert.NoError(t, err)
+	assert.Equal(t, "", result[0].ServiceName)
 }
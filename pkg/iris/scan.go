@@ -1,7 +1,9 @@
 package iris
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
 	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/services"
@@ -13,36 +15,55 @@ import (
 	workloadMap "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/services/mapper"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+	"github.com/leanix/leanix-k8s-connector/pkg/leanix"
 	"github.com/leanix/leanix-k8s-connector/pkg/logger"
+	"github.com/leanix/leanix-k8s-connector/pkg/metrics"
+	"github.com/leanix/leanix-k8s-connector/pkg/sink"
+	"github.com/leanix/leanix-k8s-connector/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/rest"
 )
 
 type Scanner interface {
-	Scan(getKubernetesApiFunc kubernetes.GetKubernetesAPI, config *rest.Config, configurationName string) error
+	Scan(ctx context.Context, getKubernetesApiFunc kubernetes.GetKubernetesAPI, config *rest.Config, configurationName string) error
+	Watch(ctx context.Context, getKubernetesApiFunc kubernetes.GetKubernetesAPI, config *rest.Config, configurationName string, resyncPeriod time.Duration) error
 }
 
 type scanner struct {
 	configService         services.ConfigService
 	eventProducer         events.EventProducer
 	workloadEventProducer workloadService.WorkloadEventProducer
-	runId                 string
-	workspaceId           string
+	// sink keeps a copy of every run, nil keeps none
+	sink        sink.Sink
+	runId       string
+	workspaceId string
+	// clusterName overrides the cluster name of the configurations, empty keeps it
+	clusterName string
+	// dryRun marks the copies of the runs in the sink, their changes were not posted
+	dryRun bool
 }
 
-func NewScanner(kind string, uri string, runId string, token string, workspaceId string) Scanner {
-	api := services.NewIrisApi(http.DefaultClient, kind, uri, token)
+func NewScanner(kind string, uri string, runId string, tokenSource leanix.TokenSource, workspaceId string, clusterName string, batchConfig services.BatchConfig, deletionGuard services.DeletionGuard, retryPolicy services.RetryPolicy, dryRun services.DryRunConfig, runSink sink.Sink) Scanner {
+	api := services.NewIrisApi(http.DefaultClient, kind, uri, tokenSource, retryPolicy)
+	if dryRun.Enabled {
+		api = services.NewDryRunIrisApi(api, dryRun)
+	}
 	configService := services.NewConfigService(api)
-	eventProducer := events.NewEventProducer(api, runId, workspaceId)
-	workloadEventProducer := workloadService.NewEventWorkloadProducer(api, runId, workspaceId)
+	eventProducer := events.NewEventProducer(api, runId, workspaceId, batchConfig, deletionGuard)
+	workloadEventProducer := workloadService.NewEventWorkloadProducer(api, runId, workspaceId, batchConfig, deletionGuard)
 	return &scanner{
 		configService:         configService,
 		eventProducer:         eventProducer,
 		workloadEventProducer: workloadEventProducer,
+		sink:                  runSink,
 		runId:                 runId,
 		workspaceId:           workspaceId,
+		clusterName:           clusterName,
+		dryRun:                dryRun.Enabled,
 	}
 }
 
@@ -55,36 +76,55 @@ const (
 	WORKLOAD    string = "WORKLOAD"
 )
 
+// ScanConfigurations scans every configuration with a scanner of its own, so each configuration
+// reports its own status under its own run id. All configurations map the same snapshot of the
+// cluster. A failing configuration does not stop the others, their errors are returned joined.
+func ScanConfigurations(ctx context.Context, newScanner func() Scanner, getKubernetesAPI kubernetes.GetKubernetesAPI, config *rest.Config, configurationNames []string) error {
+	getSnapshot := kubernetes.SharedSnapshot(getKubernetesAPI)
+	var errs []error
+	for _, configurationName := range configurationNames {
+		err := newScanner().Scan(ctx, getSnapshot, config, configurationName)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("configuration '%s': %w", configurationName, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 const StatusErrorFormat = "Scan failed while posting status. Run Id: '%s', with reason: '%v'"
 
-func (s *scanner) Scan(getKubernetesAPI kubernetes.GetKubernetesAPI, config *rest.Config, configurationName string) error {
-	configuration, err := s.configService.GetConfiguration(configurationName)
-	if err != nil {
-		return err
-	}
-	kubernetesConfig := models.KubernetesConfig{}
-	err = json.Unmarshal(configuration, &kubernetesConfig)
+func (s *scanner) Scan(ctx context.Context, getKubernetesAPI kubernetes.GetKubernetesAPI, config *rest.Config, configurationName string) (err error) {
+	ctx, span := tracing.Start(ctx, "Scan", tracing.RunIdKey.String(s.runId), attribute.String("leanix.configuration", configurationName))
+	defer func() { tracing.End(span, err) }()
+	started := time.Now()
+	kubernetesConfig, err := s.getKubernetesConfig(ctx, configurationName)
 	if err != nil {
 		return err
 	}
+	metrics.ObservePhase(metrics.PhaseConfig, time.Since(started))
+	run := sink.Run{RunId: s.runId, ConfigurationId: kubernetesConfig.ID, Cluster: kubernetesConfig.Cluster, StartedAt: started, DryRun: s.dryRun}
+	// a failed run is kept as well, with what it discovered and posted until it failed
+	defer func() { s.writeRun(context.WithoutCancel(ctx), run, err) }()
 
 	logger.Infof("Scan started for Run Id: '%s'", s.runId)
-	logger.Infof("Configuration used: %s", configuration)
 
-	err = s.ShareStatus(kubernetesConfig.ID, IN_PROGRESS, "Started Kubernetes Scan")
+	err = s.ShareStatus(ctx, kubernetesConfig.ID, IN_PROGRESS, "Started Kubernetes Scan")
 	if err != nil {
 		logger.Errorf(StatusErrorFormat, s.runId, err)
 		return err
 	}
 
-	feedbackErr := s.ShareAdminLogs(kubernetesConfig.ID, INFO, fmt.Sprintf("Scan started with Run Id: '%v'.", s.runId))
+	feedbackErr := s.ShareAdminLogs(ctx, kubernetesConfig.ID, INFO, fmt.Sprintf("Scan started with Run Id: '%v'.", s.runId))
 	if feedbackErr != nil {
 		return feedbackErr
 	}
 
+	if err := s.checkAborted(ctx, kubernetesConfig.ID); err != nil {
+		return err
+	}
 	kubernetesAPI, err := getKubernetesAPI(config)
 	if err != nil {
-		return s.LogAndShareError("Scan failed while getting Kubernetes API. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
+		return s.LogAndShareError(ctx, "Scan failed while getting Kubernetes API. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
 	}
 
 	logger.Info("Retrieved kubernetes config successfully")
@@ -93,122 +133,201 @@ func (s *scanner) Scan(getKubernetesAPI kubernetes.GetKubernetesAPI, config *res
 		return err
 	}
 
-	if kubernetesConfig.DiscoveryMode == WORKLOAD {
+	// all discovery modes map the same state of the cluster
+	kubernetesAPI = kubernetesAPI.WithSnapshot()
+	oldResults, err := s.configService.GetScanResults(ctx, kubernetesConfig.ID)
+	if err != nil {
+		return err
+	}
+	known := knownByClass(s.ownResults(oldResults, kubernetesConfig.Cluster), kubernetesConfig.DiscoveryMode)
+
+	if kubernetesConfig.DiscoveryMode.Namespaces() {
+		err = s.ScanNamespaces(ctx, kubernetesConfig, kubernetesAPI, known[models.EventClassNamespace], &run)
+		if err != nil {
+			return err
+		}
+	}
+	if kubernetesConfig.DiscoveryMode.Workloads() {
 		logger.Info("Workload scanning enabled.")
-		err = s.ShareAdminLogs(kubernetesConfig.ID, INFO, fmt.Sprintf("Workload scanning enabled for the configuration '%v'.", configurationName))
+		err = s.ShareAdminLogs(ctx, kubernetesConfig.ID, INFO, fmt.Sprintf("Workload scanning enabled for the configuration '%v'.", configurationName))
 		if err != nil {
 			logger.Errorf(StatusErrorFormat, s.runId, err)
 			return err
 		}
-		return s.ScanWorkloads(kubernetesAPI, kubernetesConfig)
+		err = s.ScanWorkloads(ctx, kubernetesAPI, kubernetesConfig, known[models.EventClassWorkload], &run)
+		if err != nil {
+			return err
+		}
 	}
+	logger.Infof("Scan Finished for Run Id: '%s'", s.runId)
+	err = s.ShareStatus(ctx, kubernetesConfig.ID, SUCCESSFUL, "Successfully Scanned")
+	if err != nil {
+		logger.Errorf(StatusErrorFormat, s.runId, err)
+		return err
+	}
+	metrics.SetLastSuccessfulScan(configurationName, time.Now())
+	return nil
+}
 
-	return s.ScanNamespaces(kubernetesConfig, kubernetesAPI)
+// ownResults keeps the results of the cluster if the scanner overrides the cluster name of the
+// configuration. A configuration shared by several clusters holds the items of all of them, the
+// items of the other clusters must not be deleted by this one. Without an override the configuration
+// belongs to this cluster alone, so the items posted under an earlier cluster name are deleted.
+func (s *scanner) ownResults(results []models.DiscoveryEvent, cluster string) []models.DiscoveryEvent {
+	if s.clusterName == "" {
+		return results
+	}
+	sourceInstance := fmt.Sprintf("cluster/%s", cluster)
+	own := make([]models.DiscoveryEvent, 0, len(results))
+	for _, result := range results {
+		if result.Body.State.SourceInstance == sourceInstance {
+			own = append(own, result)
+		}
+	}
+	return own
+}
+
+// knownByClass splits the results of the last scan by the class of their items. Results of a class
+// whose discovery mode is disabled go to the first enabled mode, so that mode deletes them. The
+// deletion guard checks them as a class of their own, turning off a mode with many items needs an
+// override.
+func knownByClass(results []models.DiscoveryEvent, modes models.DiscoveryModes) map[string][]models.DiscoveryEvent {
+	fallback := models.EventClassNamespace
+	if !modes.Namespaces() {
+		fallback = models.EventClassWorkload
+	}
+	known := map[string][]models.DiscoveryEvent{
+		models.EventClassNamespace: {},
+		models.EventClassWorkload:  {},
+	}
+	for _, result := range results {
+		class := result.HeaderProperties.Class
+		if (class == models.EventClassNamespace && modes.Namespaces()) || (class == models.EventClassWorkload && modes.Workloads()) {
+			known[class] = append(known[class], result)
+			continue
+		}
+		known[fallback] = append(known[fallback], result)
+	}
+	return known
 }
 
-func (s *scanner) ScanNamespaces(kubernetesConfig models.KubernetesConfig, kubernetesAPI *kubernetes.API) error {
-	oldResults, err := s.configService.GetScanResults(kubernetesConfig.ID)
+func (s *scanner) getKubernetesConfig(ctx context.Context, configurationName string) (models.KubernetesConfig, error) {
+	kubernetesConfig := models.KubernetesConfig{}
+	configuration, err := s.configService.GetConfiguration(ctx, configurationName)
 	if err != nil {
-		return err
+		return kubernetesConfig, err
+	}
+	err = json.Unmarshal(configuration, &kubernetesConfig)
+	if err != nil {
+		return kubernetesConfig, err
+	}
+	if s.clusterName != "" {
+		kubernetesConfig.Cluster = s.clusterName
 	}
+	logger.Infof("Configuration used: %s", configuration)
+	return kubernetesConfig, nil
+}
 
+// ScanNamespaces posts the changes of the namespaces compared to the old results of the namespace
+// class and adds the namespaces and their changes to run
+func (s *scanner) ScanNamespaces(ctx context.Context, kubernetesConfig models.KubernetesConfig, kubernetesAPI *kubernetes.API, oldResults []models.DiscoveryEvent, run *sink.Run) error {
+	started, listedBefore := time.Now(), kubernetesAPI.ListDuration()
 	mapper := namespaceMap.NewMapper(kubernetesAPI, kubernetesConfig.Cluster, s.workspaceId, kubernetesConfig.BlackListedNamespaces, s.runId)
 
-	nodes, err := kubernetesAPI.Nodes()
+	nodes, err := kubernetesAPI.Nodes(ctx)
 	if err != nil {
-		return s.LogAndShareError("Scan failed while retrieving k8s cluster nodes. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
+		return s.LogAndShareError(ctx, "Scan failed while retrieving k8s cluster nodes. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
 	}
 
 	clusterDTO, err := mapper.MapCluster(kubernetesConfig.Cluster, nodes)
 	if err != nil {
-		return s.LogAndShareError("Scan failed while aggregating cluster information. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
+		return s.LogAndShareError(ctx, "Scan failed while aggregating cluster information. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
 	}
-	feedbackErr := s.ShareAdminLogs(kubernetesConfig.ID, INFO, fmt.Sprintf("Namespace scanning enabled for the cluster '%v'.", clusterDTO.Name))
+	feedbackErr := s.ShareAdminLogs(ctx, kubernetesConfig.ID, INFO, fmt.Sprintf("Namespace scanning enabled for the cluster '%v'.", clusterDTO.Name))
 	if feedbackErr != nil {
 		return feedbackErr
 	}
 
 	// Aggregate cluster information for the event
-	namespaces, err := kubernetesAPI.Namespaces(kubernetesConfig.BlackListedNamespaces)
+	namespaces, err := kubernetesAPI.Namespaces(ctx, kubernetesConfig.BlackListedNamespaces)
 	if err != nil {
-		return s.LogAndShareError("Scan failed while retrieving Kubernetes namespaces. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
+		return s.LogAndShareError(ctx, "Scan failed while retrieving Kubernetes namespaces. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
 	}
 	//Fetch old scan results
-	ecstDiscoveredData, err := s.ProcessNamespace(kubernetesAPI, mapper, namespaces.Items, clusterDTO)
+	ecstDiscoveredData, err := s.ProcessNamespace(ctx, kubernetesAPI, mapper, namespaces.Items, clusterDTO)
 	if err != nil {
-		return s.LogAndShareError("Scan failed while retrieving k8s deployments. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
+		return s.LogAndShareError(ctx, "Scan failed while retrieving k8s deployments. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
 	}
+	observeMapping(kubernetesAPI, started, listedBefore)
+	metrics.SetDiscoveredItems(clusterDTO.Name, map[string]int{"namespace": len(ecstDiscoveredData)})
 
-	err = s.eventProducer.ProcessResults(ecstDiscoveredData, oldResults, kubernetesConfig.ID)
-	if err != nil {
-		return s.LogAndShareError("Scan failed while posting ECST results. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
+	if err := s.checkAborted(ctx, kubernetesConfig.ID); err != nil {
+		return err
 	}
-
-	feedbackErr = s.ShareAdminLogs(kubernetesConfig.ID, INFO, fmt.Sprintf("Found and processed %v unblacklisted namespaces from the cluster '%v'.", len(namespaces.Items), clusterDTO.Name))
-	if feedbackErr != nil {
-		return feedbackErr
+	report, err := s.eventProducer.ProcessResults(ctx, ecstDiscoveredData, oldResults, kubernetesConfig.ID)
+	if err != nil {
+		return s.LogAndShareError(ctx, processErrorFormat(err), ERROR, err, kubernetesConfig.ID)
 	}
-
-	logger.Infof("Scan Finished for RunId: '%s'", s.runId)
-	err = s.ShareStatus(kubernetesConfig.ID, SUCCESSFUL, "Successfully Scanned")
+	err = s.shareProcessReport(ctx, kubernetesConfig.ID, report)
 	if err != nil {
-		logger.Errorf(StatusErrorFormat, s.runId, err)
-		return err
+		return s.LogAndShareError(ctx, "Scan failed while posting ECST results. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
 	}
-	return err
+	run.Items = append(run.Items, s.namespaceStates(kubernetesConfig.ID, ecstDiscoveredData)...)
+	run.Changes = append(run.Changes, report.Changes...)
+
+	return s.ShareAdminLogs(ctx, kubernetesConfig.ID, INFO, fmt.Sprintf("Found and processed %v unblacklisted namespaces from the cluster '%v'.", len(namespaces.Items), clusterDTO.Name))
 }
 
-func (s *scanner) ScanWorkloads(kubernetesAPI *kubernetes.API, kubernetesConfig models.KubernetesConfig) error {
-	mapper := workloadMap.NewMapper(kubernetesAPI, kubernetesConfig.Cluster, s.workspaceId, s.runId)
+// ScanWorkloads posts the changes of the workloads compared to the old results of the workload
+// class and adds the workloads and their changes to run
+func (s *scanner) ScanWorkloads(ctx context.Context, kubernetesAPI *kubernetes.API, kubernetesConfig models.KubernetesConfig, oldResults []models.DiscoveryEvent, run *sink.Run) error {
+	started, listedBefore := time.Now(), kubernetesAPI.ListDuration()
+	mapper := workloadMap.NewMapper(kubernetesAPI, kubernetesConfig.Cluster, s.workspaceId, s.runId, kubernetesConfig.CustomWorkloads)
 
-	nodes, err := kubernetesAPI.Nodes()
+	nodes, err := kubernetesAPI.Nodes(ctx)
 	if err != nil {
-		return s.LogAndShareError("Scan failed while retrieving k8s cluster nodes. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
+		return s.LogAndShareError(ctx, "Scan failed while retrieving k8s cluster nodes. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
 	}
 
 	clusterInfo, err := mapper.MapCluster(kubernetesConfig.Cluster, nodes)
 	if err != nil {
-		return s.LogAndShareError("Scan failed while aggregating cluster information. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
+		return s.LogAndShareError(ctx, "Scan failed while aggregating cluster information. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
 	}
 
-	discoveredWorkloads, err := s.ProcessWorkloads(mapper, clusterInfo)
+	discoveredWorkloads, err := s.ProcessWorkloads(ctx, mapper, clusterInfo)
 	if err != nil {
-		return s.LogAndShareError("Scan failed while retrieving k8s workload. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
+		return s.LogAndShareError(ctx, "Scan failed while retrieving k8s workload. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
 	}
-	oldResults, err := s.configService.GetScanResults(kubernetesConfig.ID)
-	if err != nil {
+	observeMapping(kubernetesAPI, started, listedBefore)
+	metrics.SetDiscoveredItems(clusterInfo.Name, countByType(discoveredWorkloads))
+	if err := s.checkAborted(ctx, kubernetesConfig.ID); err != nil {
 		return err
 	}
-	err = s.workloadEventProducer.ProcessWorkloads(discoveredWorkloads, oldResults, kubernetesConfig.ID)
+	report, err := s.workloadEventProducer.ProcessWorkloads(ctx, discoveredWorkloads, oldResults, kubernetesConfig.ID)
 	if err != nil {
-		return s.LogAndShareError("Scan failed while posting ECST results. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
+		return s.LogAndShareError(ctx, processErrorFormat(err), ERROR, err, kubernetesConfig.ID)
 	}
-
-	feedbackErr := s.ShareAdminLogs(kubernetesConfig.ID, INFO, fmt.Sprintf("Found and processed %v workloads from the cluster '%v'.", len(discoveredWorkloads), clusterInfo.Name))
-	if feedbackErr != nil {
-		return feedbackErr
-	}
-
-	logger.Infof("Scan Finished for Run Id: '%s'", s.runId)
-	err = s.ShareStatus(kubernetesConfig.ID, SUCCESSFUL, "Successfully Scanned")
+	err = s.shareProcessReport(ctx, kubernetesConfig.ID, report)
 	if err != nil {
-		logger.Errorf(StatusErrorFormat, s.runId, err)
-		return err
+		return s.LogAndShareError(ctx, "Scan failed while posting ECST results. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
 	}
-	return err
+	run.Items = append(run.Items, s.workloadStates(kubernetesConfig.ID, discoveredWorkloads)...)
+	run.Changes = append(run.Changes, report.Changes...)
+
+	return s.ShareAdminLogs(ctx, kubernetesConfig.ID, INFO, fmt.Sprintf("Found and processed %v workloads from the cluster '%v'.", len(discoveredWorkloads), clusterInfo.Name))
 }
 
-func (s *scanner) ProcessNamespace(k8sApi *kubernetes.API, mapper namespaceMap.Mapper, namespaces []corev1.Namespace, cluster namespaceMap.ClusterDTO) ([]namespaceModels.Data, error) {
+func (s *scanner) ProcessNamespace(ctx context.Context, k8sApi *kubernetes.API, mapper namespaceMap.Mapper, namespaces []corev1.Namespace, cluster namespaceMap.ClusterDTO) ([]namespaceModels.Data, error) {
 	var ecstData = make([]namespaceModels.Data, 0)
 
 	for _, namespace := range namespaces {
 		// collect all deployments
-		deployments, err := k8sApi.Deployments(namespace.Name)
+		deployments, err := k8sApi.Deployments(ctx, namespace.Name)
 		if err != nil {
 			return nil, err
 		}
 
-		services, err := k8sApi.Services(namespace.Name)
+		services, err := k8sApi.Services(ctx, namespace.Name)
 		if err != nil {
 			return nil, err
 		}
@@ -226,17 +345,134 @@ func (s *scanner) ProcessNamespace(k8sApi *kubernetes.API, mapper namespaceMap.M
 	return ecstData, nil
 }
 
-func (s *scanner) ProcessWorkloads(mapper workloadMap.WorkloadMapper, clusterInfo workload.Cluster) ([]workload.Data, error) {
-	return mapper.MapWorkloads(clusterInfo)
+func (s *scanner) ProcessWorkloads(ctx context.Context, mapper workloadMap.WorkloadMapper, clusterInfo workload.Cluster) ([]workload.Data, error) {
+	return mapper.MapWorkloads(ctx, clusterInfo)
+}
+
+// observeMapping records the time since started as mapping phase, except for the time spent
+// listing Kubernetes resources since the api had spent listedBefore, which is the listing phase
+func observeMapping(kubernetesAPI *kubernetes.API, started time.Time, listedBefore time.Duration) {
+	listed := kubernetesAPI.ListDuration() - listedBefore
+	metrics.ObservePhase(metrics.PhaseListing, listed)
+	metrics.ObservePhase(metrics.PhaseMapping, time.Since(started)-listed)
+}
+
+// countByType counts the workloads per workload type
+func countByType(workloads []workload.Data) map[string]int {
+	counts := map[string]int{}
+	for _, item := range workloads {
+		counts[item.Workload.WorkloadType]++
+	}
+	return counts
+}
+
+// namespaceStates creates the state events of the discovered namespaces
+func (s *scanner) namespaceStates(configId string, discovered []namespaceModels.Data) []models.DiscoveryEvent {
+	states := make([]models.DiscoveryEvent, 0, len(discovered))
+	for _, item := range discovered {
+		id := namespaceModels.GenerateId(s.workspaceId, configId, item)
+		states = append(states, namespaceModels.CreateEcstDiscoveryEvent(models.EVENT_TYPE_STATE, "", id, item, s.workspaceId, configId))
+	}
+	return states
+}
+
+// workloadStates creates the state events of the discovered workloads
+func (s *scanner) workloadStates(configId string, discovered []workload.Data) []models.DiscoveryEvent {
+	states := make([]models.DiscoveryEvent, 0, len(discovered))
+	for _, item := range discovered {
+		id := workload.GenerateId(s.workspaceId, configId, item)
+		states = append(states, workload.CreateEcstDiscoveryEvent(models.EVENT_TYPE_STATE, "", id, item, s.runId, s.workspaceId, configId))
+	}
+	return states
+}
+
+// writeRun keeps a copy of the run in the sink, failed if runErr is set. A failing sink does not
+// fail the run, its results are posted to Iris already.
+func (s *scanner) writeRun(ctx context.Context, run sink.Run, runErr error) {
+	if s.sink == nil {
+		return
+	}
+	run.Status = sink.StatusSuccessful
+	if runErr != nil {
+		run.Status = sink.StatusFailed
+		run.Error = runErr.Error()
+	}
+	err := s.sink.Write(ctx, run)
+	if err != nil {
+		logger.Errorf("Failed writing the copy of Run Id: '%s' to the storage backend, with reason: '%v'", s.runId, err)
+		logErr := s.ShareAdminLogs(ctx, run.ConfigurationId, ERROR, fmt.Sprintf("The copy of the run could not be written to the storage backend: %v", err))
+		if logErr != nil {
+			logger.Errorf(StatusErrorFormat, s.runId, logErr)
+		}
+	}
+}
+
+// checkAborted fails the run if ctx has been cancelled, e.g. because this replica lost the leader
+// election, so nothing is posted to Iris after another replica may have taken over.
+func (s *scanner) checkAborted(ctx context.Context, id string) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+	return s.LogAndShareError(ctx, "Scan aborted. Run Id: '%s', with reason: '%v'", ERROR, context.Cause(ctx), id)
+}
+
+// processErrorFormat tells a run stopped by the deletion guard apart from a failure to post the results
+func processErrorFormat(err error) string {
+	var massDeletion *services.MassDeletionError
+	if errors.As(err, &massDeletion) {
+		return "Scan aborted before posting ECST results, the discovered items look incomplete. Run Id: '%s', with reason: '%v'"
+	}
+	return "Scan failed while posting ECST results. Run Id: '%s', with reason: '%v'"
+}
+
+// shareProcessReport counts the accepted events, shares the changed fields of the updated items and
+// the outcome of every posted batch in the admin logs and returns an error unless all batches were
+// accepted
+func (s *scanner) shareProcessReport(ctx context.Context, configId string, processReport services.ProcessReport) error {
+	// a dry run posts nothing
+	if !s.dryRun {
+		countEvents(processReport.Batches.Accepted(processReport.Changes))
+	}
+	if len(processReport.Updated) > 0 {
+		feedbackErr := s.ShareAdminLogs(ctx, configId, INFO, services.SummarizeUpdates(processReport.Updated))
+		if feedbackErr != nil {
+			return feedbackErr
+		}
+	}
+	report := processReport.Batches
+	for _, result := range report {
+		if result.Err != nil {
+			logger.Errorf("Batch %d/%d with %d ECST events (%d bytes) was not accepted for Run Id: '%s', with reason: '%v'", result.Index+1, len(report), result.Events, result.Bytes, s.runId, result.Err)
+			feedbackErr := s.ShareAdminLogs(ctx, configId, ERROR, fmt.Sprintf("Batch %d/%d with %d ECST events (%d bytes) was not accepted: %v", result.Index+1, len(report), result.Events, result.Bytes, result.Err))
+			if feedbackErr != nil {
+				return feedbackErr
+			}
+			continue
+		}
+		feedbackErr := s.ShareAdminLogs(ctx, configId, INFO, fmt.Sprintf("Batch %d/%d with %d ECST events (%d bytes) was accepted.", result.Index+1, len(report), result.Events, result.Bytes))
+		if feedbackErr != nil {
+			return feedbackErr
+		}
+	}
+	return report.Err()
+}
+
+// countEvents adds the posted events to the metrics by their action
+func countEvents(posted []models.DiscoveryEvent) {
+	actions := map[string]int{}
+	for _, event := range posted {
+		actions[event.HeaderProperties.Action]++
+	}
+	metrics.CountEvents(actions[models.EventActionCreated], actions[models.EventActionUpdated], actions[models.EventActionDeleted])
 }
 
-func (s *scanner) LogAndShareError(message string, loglevel string, err error, id string) error {
+func (s *scanner) LogAndShareError(ctx context.Context, message string, loglevel string, err error, id string) error {
 	logger.Errorf(message, s.runId, err)
-	statusErr := s.ShareStatus(id, FAILED, "Kubernetes scan failed")
+	statusErr := s.ShareStatus(ctx, id, FAILED, "Kubernetes scan failed")
 	if statusErr != nil {
 		logger.Errorf(StatusErrorFormat, s.runId, statusErr)
 	}
-	logErr := s.ShareAdminLogs(id, loglevel, fmt.Sprintf(message, s.runId, err))
+	logErr := s.ShareAdminLogs(ctx, id, loglevel, fmt.Sprintf(message, s.runId, err))
 	if logErr != nil {
 		logger.Errorf(StatusErrorFormat, s.runId, logErr)
 	}
@@ -258,12 +494,12 @@ func (s *scanner) CreateEcstDiscoveryData(currentNamespace corev1.Namespace, dep
 	}
 }
 
-func (s *scanner) ShareStatus(configid string, status string, message string) error {
+func (s *scanner) ShareStatus(ctx context.Context, configid string, status string, message string) error {
 	var statusArray []models.StatusItem
 	statusObject := models.NewStatusEvent(configid, s.runId, s.workspaceId, status, message)
 	statusArray = append(statusArray, *statusObject)
 	statusByte, err := json.Marshal(statusArray)
-	err = s.eventProducer.PostStatus(statusByte)
+	err = s.eventProducer.PostStatus(ctx, statusByte)
 	if err != nil {
 		logger.Debugf("Failed sharing status for Run Id: '%s', with reason %v", s.runId, err)
 		return err
@@ -271,12 +507,12 @@ func (s *scanner) ShareStatus(configid string, status string, message string) er
 	return nil
 }
 
-func (s *scanner) ShareAdminLogs(configId string, loglevel string, message string) error {
+func (s *scanner) ShareAdminLogs(ctx context.Context, configId string, loglevel string, message string) error {
 	var statusArray []models.StatusItem
 	statusObject := models.NewAdminLogEvent(configId, s.runId, s.workspaceId, loglevel, message)
 	statusArray = append(statusArray, *statusObject)
 	statusByte, err := json.Marshal(statusArray)
-	err = s.eventProducer.PostStatus(statusByte)
+	err = s.eventProducer.PostStatus(ctx, statusByte)
 	if err != nil {
 		logger.Debugf("Failed sharing admin logs for Run Id: '%s', with reason %v", s.runId, err)
 		return err
@@ -0,0 +1,304 @@
+package iris
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/services"
+	namespaceModels "github.com/leanix/leanix-k8s-connector/pkg/iris/namespaces/models"
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/namespaces/services/events"
+	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+	workloadService "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/services/events"
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+	"github.com/leanix/leanix-k8s-connector/pkg/sink"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// fakeIrisApi serves a configuration and scan results and records everything posted
+type fakeIrisApi struct {
+	configurations map[string]string
+	results        []models.DiscoveryEvent
+
+	mu          sync.Mutex
+	resultCalls int
+	posted      []models.DiscoveryEvent
+	status      []models.StatusItem
+}
+
+func (a *fakeIrisApi) GetConfiguration(_ context.Context, configurationName string) ([]byte, error) {
+	return []byte(a.configurations[configurationName]), nil
+}
+
+func (a *fakeIrisApi) GetScanResults(context.Context, string) ([]models.DiscoveryEvent, error) {
+	a.mu.Lock()
+	a.resultCalls++
+	a.mu.Unlock()
+	// results read from Iris carry generic maps as data
+	marshalled, err := json.Marshal(a.results)
+	if err != nil {
+		return nil, err
+	}
+	var results []models.DiscoveryEvent
+	return results, json.Unmarshal(marshalled, &results)
+}
+
+func (a *fakeIrisApi) PostEcstResults(_ context.Context, ecstResults []byte) error {
+	var events []models.DiscoveryEvent
+	if err := json.Unmarshal(ecstResults, &events); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.posted = append(a.posted, events...)
+	return nil
+}
+
+func (a *fakeIrisApi) PostStatus(_ context.Context, status []byte) error {
+	var items []models.StatusItem
+	if err := json.Unmarshal(status, &items); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.status = append(a.status, items...)
+	return nil
+}
+
+func newTestScanner(api services.IrisApi) *scanner {
+	return newTestScannerWithRunId(api, "runId")
+}
+
+func newTestScannerWithRunId(api services.IrisApi, runId string) *scanner {
+	return &scanner{
+		configService:         services.NewConfigService(api),
+		eventProducer:         events.NewEventProducer(api, runId, "workspaceId", services.DefaultBatchConfig, services.DeletionGuard{}),
+		workloadEventProducer: workloadService.NewEventWorkloadProducer(api, runId, "workspaceId", services.DefaultBatchConfig, services.DeletionGuard{}),
+		runId:                 runId,
+		workspaceId:           "workspaceId",
+	}
+}
+
+func TestScan_allDiscoveryModes(t *testing.T) {
+	setup()
+	staleNamespace := namespaceModels.Data{Cluster: namespaceModels.ClusterEcst{Name: "prod", Namespace: "old"}}
+	staleWorkload := workload.Data{
+		Workload:      workload.Workload{Name: "old", WorkloadType: "deployment"},
+		Cluster:       workload.Cluster{Name: "prod"},
+		NamespaceName: "web",
+	}
+	api := &fakeIrisApi{
+		configurations: map[string]string{"config": `{"id": "configId", "cluster": "prod", "discoveryMode": "NAMESPACE,WORKLOAD"}`},
+		results: []models.DiscoveryEvent{
+			namespaceModels.CreateEcstDiscoveryEvent(models.EVENT_TYPE_STATE, "", "old-namespace", staleNamespace, "workspaceId", "configId"),
+			workload.CreateEcstDiscoveryEvent(models.EVENT_TYPE_STATE, "", "old-workload", staleWorkload, "runId", "workspaceId", "configId"),
+		},
+	}
+	client := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "web"}},
+	)
+	lists := map[string]int{}
+	client.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		lists[action.GetResource().Resource]++
+		return false, nil, nil
+	})
+	getKubernetesAPI := func(*rest.Config) (*kubernetes.API, error) {
+		return &kubernetes.API{Client: client}, nil
+	}
+
+	err := newTestScanner(api).Scan(context.Background(), getKubernetesAPI, nil, "config")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, api.resultCalls)
+	assert.Equal(t, 1, lists["nodes"])
+	assert.Equal(t, 1, lists["deployments"])
+	assert.Equal(t, 1, lists["services"])
+	actions := map[string][]string{}
+	for _, event := range api.posted {
+		actions[event.HeaderProperties.Class] = append(actions[event.HeaderProperties.Class], event.HeaderProperties.Action+" "+event.Body.State.Name)
+	}
+	assert.ElementsMatch(t, []string{"created web", "deleted old"}, actions[models.EventClassNamespace])
+	assert.ElementsMatch(t, []string{"created deployment/shop", "deleted deployment/old"}, actions[models.EventClassWorkload])
+	assert.Equal(t, "SUCCESSFUL", api.status[len(api.status)-1].Subject)
+}
+
+// recordingSink records the runs written to it
+type recordingSink struct {
+	runs []sink.Run
+}
+
+func (s *recordingSink) Write(_ context.Context, run sink.Run) error {
+	s.runs = append(s.runs, run)
+	return nil
+}
+
+func TestScan_writesRunToSink(t *testing.T) {
+	setup()
+	api := &fakeIrisApi{
+		configurations: map[string]string{"config": `{"id": "configId", "cluster": "prod", "discoveryMode": "NAMESPACE,WORKLOAD"}`},
+	}
+	client := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "web"}},
+	)
+	getKubernetesAPI := func(*rest.Config) (*kubernetes.API, error) {
+		return &kubernetes.API{Client: client}, nil
+	}
+	runSink := &recordingSink{}
+	s := newTestScanner(api)
+	s.sink = runSink
+
+	err := s.Scan(context.Background(), getKubernetesAPI, nil, "config")
+
+	assert.NoError(t, err)
+	assert.Len(t, runSink.runs, 1)
+	run := runSink.runs[0]
+	assert.Equal(t, "runId", run.RunId)
+	assert.Equal(t, "configId", run.ConfigurationId)
+	assert.Equal(t, "prod", run.Cluster)
+	assert.Equal(t, sink.StatusSuccessful, run.Status)
+	assert.False(t, run.DryRun)
+	assert.False(t, run.StartedAt.IsZero())
+	assert.Len(t, run.Items, 2)
+	for _, item := range run.Items {
+		assert.Equal(t, models.EVENT_TYPE_STATE, item.HeaderProperties.Type)
+	}
+	// the posted events are read back with generic maps as data, so only their headers are compared
+	changes := make([]models.HeaderProperties, 0, len(run.Changes))
+	for _, event := range run.Changes {
+		changes = append(changes, event.HeaderProperties)
+	}
+	posted := make([]models.HeaderProperties, 0, len(api.posted))
+	for _, event := range api.posted {
+		posted = append(posted, event.HeaderProperties)
+	}
+	assert.Len(t, changes, 2)
+	assert.ElementsMatch(t, posted, changes)
+}
+
+func TestScan_writesFailedRunToSink(t *testing.T) {
+	setup()
+	api := &fakeIrisApi{
+		configurations: map[string]string{"config": `{"id": "configId", "cluster": "prod", "discoveryMode": "NAMESPACE"}`},
+	}
+	getKubernetesAPI := func(*rest.Config) (*kubernetes.API, error) {
+		return nil, errors.New("no access")
+	}
+	runSink := &recordingSink{}
+	s := newTestScanner(api)
+	s.sink = runSink
+	s.dryRun = true
+
+	err := s.Scan(context.Background(), getKubernetesAPI, nil, "config")
+
+	assert.Error(t, err)
+	assert.Len(t, runSink.runs, 1)
+	run := runSink.runs[0]
+	assert.Equal(t, "configId", run.ConfigurationId)
+	assert.Equal(t, sink.StatusFailed, run.Status)
+	assert.Contains(t, run.Error, "no access")
+	assert.True(t, run.DryRun)
+	assert.Empty(t, run.Items)
+}
+
+func TestKnownByClass_disabledMode(t *testing.T) {
+	results := []models.DiscoveryEvent{
+		{HeaderProperties: models.HeaderProperties{Class: models.EventClassNamespace, Id: "namespace"}},
+		{HeaderProperties: models.HeaderProperties{Class: models.EventClassWorkload, Id: "workload"}},
+	}
+
+	known := knownByClass(results, models.DiscoveryModes{models.DiscoveryModeWorkload})
+
+	// the namespaces left from an earlier mode are deleted by the workload mode
+	assert.Empty(t, known[models.EventClassNamespace])
+	assert.Len(t, known[models.EventClassWorkload], 2)
+}
+
+func TestScanConfigurations(t *testing.T) {
+	setup()
+	api := &fakeIrisApi{
+		configurations: map[string]string{
+			"all":      `{"id": "allId", "cluster": "prod"}`,
+			"no-web":   `{"id": "noWebId", "cluster": "prod", "blacklistedNamespaces": ["web"]}`,
+			"workload": `{"id": "workloadId", "cluster": "prod", "discoveryMode": "WORKLOAD"}`,
+		},
+	}
+	client := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+	)
+	lists := map[string]int{}
+	client.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		lists[action.GetResource().Resource]++
+		return false, nil, nil
+	})
+	getKubernetesAPI := func(*rest.Config) (*kubernetes.API, error) {
+		return &kubernetes.API{Client: client}, nil
+	}
+	runIds := []string{"run-1", "run-2", "run-3"}
+	newScanner := func() Scanner {
+		runId := runIds[0]
+		runIds = runIds[1:]
+		return newTestScannerWithRunId(api, runId)
+	}
+
+	err := ScanConfigurations(context.Background(), newScanner, getKubernetesAPI, nil, []string{"all", "no-web", "workload"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, lists["nodes"])
+	assert.Equal(t, 1, lists["namespaces"])
+	namespaces := map[string][]string{}
+	for _, event := range api.posted {
+		if event.HeaderProperties.Class == models.EventClassNamespace {
+			namespaces[event.HeaderProperties.Scope] = append(namespaces[event.HeaderProperties.Scope], event.Body.State.Name)
+		}
+	}
+	assert.ElementsMatch(t, []string{"shop", "web"}, namespaces["workspace/workspaceId/configuration/allId"])
+	assert.ElementsMatch(t, []string{"shop"}, namespaces["workspace/workspaceId/configuration/noWebId"])
+	successful := map[string]string{}
+	for _, status := range api.status {
+		if status.Subject == SUCCESSFUL {
+			successful[status.ID] = status.Source
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"allId":      "kubernetes/allId#run-1",
+		"noWebId":    "kubernetes/noWebId#run-2",
+		"workloadId": "kubernetes/workloadId#run-3",
+	}, successful)
+}
+
+func TestScanConfigurations_failingConfiguration(t *testing.T) {
+	setup()
+	api := &fakeIrisApi{
+		configurations: map[string]string{
+			"broken": `not json`,
+			"prod":   `{"id": "prodId", "cluster": "prod"}`,
+		},
+	}
+	getKubernetesAPI := func(*rest.Config) (*kubernetes.API, error) {
+		return &kubernetes.API{Client: fake.NewSimpleClientset()}, nil
+	}
+	newScanner := func() Scanner {
+		return newTestScanner(api)
+	}
+
+	err := ScanConfigurations(context.Background(), newScanner, getKubernetesAPI, nil, []string{"broken", "prod"})
+
+	assert.ErrorContains(t, err, "configuration 'broken'")
+	assert.Equal(t, SUCCESSFUL, api.status[len(api.status)-1].Subject)
+}
@@ -0,0 +1,287 @@
+package iris
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	namespaceMap "github.com/leanix/leanix-k8s-connector/pkg/iris/namespaces/services/mapper"
+	workloadMap "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/services/mapper"
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+	"github.com/leanix/leanix-k8s-connector/pkg/logger"
+	"github.com/leanix/leanix-k8s-connector/pkg/sink"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// WatchDebounce is the time the watch waits for further changes before it maps the cluster again,
+// so a rollout touching many objects results in a single sync.
+const WatchDebounce = 5 * time.Second
+
+// syncFunc maps the current state of the cluster, posts the difference to the known state to Iris
+// and returns the new known state.
+type syncFunc func(ctx context.Context, kubernetesAPI *kubernetes.API, kubernetesConfig models.KubernetesConfig, known []models.DiscoveryEvent) ([]models.DiscoveryEvent, error)
+
+// WatchConfigurations watches every configuration with a scanner of its own until ctx is cancelled,
+// so each configuration reports its own status under its own run id. All watches share one set of
+// informers, the cluster is cached and watched only once. The errors of the watches are returned
+// joined.
+func WatchConfigurations(ctx context.Context, newScanner func() Scanner, getKubernetesAPI kubernetes.GetKubernetesAPI, config *rest.Config, configurationNames []string, resyncPeriod time.Duration) error {
+	getKubernetesAPI = kubernetes.SharedInformers(getKubernetesAPI, ctx.Done())
+	errs := make([]error, len(configurationNames))
+	var wg sync.WaitGroup
+	for i, configurationName := range configurationNames {
+		wg.Add(1)
+		go func(i int, configurationName string) {
+			defer wg.Done()
+			err := newScanner().Watch(ctx, getKubernetesAPI, config, configurationName, resyncPeriod)
+			if err != nil {
+				errs[i] = fmt.Errorf("configuration '%s': %w", configurationName, err)
+			}
+		}(i, configurationName)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Watch keeps running until ctx is cancelled. It keeps the cluster state in shared informers and
+// posts ECST events for the affected items whenever watched objects change, changes of their
+// status alone wait for the resync. An api which already
+// has informers, e.g. from SharedInformers, is watched with those. Every resyncPeriod the known
+// state is replaced with the latest results from Iris and a full sync is done.
+func (s *scanner) Watch(ctx context.Context, getKubernetesAPI kubernetes.GetKubernetesAPI, config *rest.Config, configurationName string, resyncPeriod time.Duration) error {
+	kubernetesConfig, err := s.getKubernetesConfig(ctx, configurationName)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Watch started for Run Id: '%s'", s.runId)
+	err = s.ShareStatus(ctx, kubernetesConfig.ID, IN_PROGRESS, "Started Kubernetes Watch")
+	if err != nil {
+		logger.Errorf(StatusErrorFormat, s.runId, err)
+		return err
+	}
+
+	cachedAPI, err := kubernetes.SharedInformers(getKubernetesAPI, ctx.Done())(config)
+	if err != nil {
+		return s.LogAndShareError(ctx, "Watch failed while getting Kubernetes API. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
+	}
+
+	sync := s.syncModes(kubernetesConfig.DiscoveryMode)
+
+	changes := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+	// the informers may be synced already, the objects listed initially are covered by the initial sync
+	err = cachedAPI.Informers().AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(_ interface{}, isInInitialList bool) {
+			if !isInInitialList {
+				notify()
+			}
+		},
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			if !statusOnly(oldObj, newObj) {
+				notify()
+			}
+		},
+		DeleteFunc: func(interface{}) { notify() },
+	})
+	if err != nil {
+		return s.LogAndShareError(ctx, "Watch failed while registering informers. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
+	}
+
+	known, err := s.resync(ctx, cachedAPI, kubernetesConfig, sync)
+	if err != nil {
+		return s.LogAndShareError(ctx, "Watch failed during the initial sync. Run Id: '%s', with reason: '%v'", ERROR, err, kubernetesConfig.ID)
+	}
+	feedbackErr := s.ShareAdminLogs(ctx, kubernetesConfig.ID, INFO, fmt.Sprintf("Watching cluster '%v' for changes with Run Id: '%v'.", kubernetesConfig.Cluster, s.runId))
+	if feedbackErr != nil {
+		return feedbackErr
+	}
+
+	ticker := time.NewTicker(resyncPeriod)
+	defer ticker.Stop()
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			// a replica which lost the leader election was stopped before its watch was done
+			if errors.Is(context.Cause(ctx), kubernetes.ErrLeadershipLost) {
+				return s.LogAndShareError(ctx, "Watch aborted. Run Id: '%s', with reason: '%v'", ERROR, context.Cause(ctx), kubernetesConfig.ID)
+			}
+			logger.Infof("Watch stopped for Run Id: '%s', with reason: '%v'", s.runId, context.Cause(ctx))
+			return s.ShareStatus(ctx, kubernetesConfig.ID, SUCCESSFUL, fmt.Sprintf("Stopped Kubernetes Watch: %v", context.Cause(ctx)))
+		case <-changes:
+			if debounce == nil {
+				debounce = time.After(WatchDebounce)
+			}
+		case <-debounce:
+			debounce = nil
+			if ctx.Err() != nil {
+				continue
+			}
+			updated, err := sync(ctx, cachedAPI, kubernetesConfig, known)
+			if err != nil {
+				s.logAndShareWatchError(ctx, "Watch failed to sync changes. Run Id: '%s', with reason: '%v'", err, kubernetesConfig.ID)
+				continue
+			}
+			known = updated
+		case <-ticker.C:
+			if ctx.Err() != nil {
+				continue
+			}
+			updated, err := s.resync(ctx, cachedAPI, kubernetesConfig, sync)
+			if err != nil {
+				s.logAndShareWatchError(ctx, "Watch failed to resync with Iris. Run Id: '%s', with reason: '%v'", err, kubernetesConfig.ID)
+				continue
+			}
+			known = updated
+		}
+	}
+}
+
+// statusOnly tells whether an update changed no more than the status of an object, e.g. a pod
+// becoming ready or a restarted container. Those updates are frequent on a busy cluster, the
+// statuses the mapping reads, like replicas and running images, are picked up by the next resync.
+func statusOnly(oldObj interface{}, newObj interface{}) bool {
+	oldObject, oldOk := oldObj.(metav1.Object)
+	newObject, newOk := newObj.(metav1.Object)
+	if !oldOk || !newOk {
+		return false
+	}
+	// the informers resync their cache with unchanged objects
+	if oldObject.GetResourceVersion() == newObject.GetResourceVersion() {
+		return true
+	}
+	if !reflect.DeepEqual(oldObject.GetLabels(), newObject.GetLabels()) ||
+		!reflect.DeepEqual(oldObject.GetAnnotations(), newObject.GetAnnotations()) ||
+		!reflect.DeepEqual(oldObject.GetOwnerReferences(), newObject.GetOwnerReferences()) {
+		return false
+	}
+	// pods have no generation counting the changes of their spec
+	if oldPod, ok := oldObj.(*corev1.Pod); ok {
+		newPod, ok := newObj.(*corev1.Pod)
+		return ok && reflect.DeepEqual(oldPod.Spec, newPod.Spec)
+	}
+	return newObject.GetGeneration() != 0 && oldObject.GetGeneration() == newObject.GetGeneration()
+}
+
+// resync does a full sync against the latest scan results stored in Iris
+func (s *scanner) resync(ctx context.Context, kubernetesAPI *kubernetes.API, kubernetesConfig models.KubernetesConfig, sync syncFunc) ([]models.DiscoveryEvent, error) {
+	oldResults, err := s.configService.GetScanResults(ctx, kubernetesConfig.ID)
+	if err != nil {
+		return nil, err
+	}
+	return sync(ctx, kubernetesAPI, kubernetesConfig, s.ownResults(oldResults, kubernetesConfig.Cluster))
+}
+
+// logAndShareWatchError reports a failed sync without failing the run, the watch carries on and
+// the next change or resync tries again.
+func (s *scanner) logAndShareWatchError(ctx context.Context, message string, err error, id string) {
+	logger.Errorf(message, s.runId, err)
+	logErr := s.ShareAdminLogs(ctx, id, ERROR, fmt.Sprintf(message, s.runId, err))
+	if logErr != nil {
+		logger.Errorf(StatusErrorFormat, s.runId, logErr)
+	}
+}
+
+// syncModes syncs every enabled discovery mode against the known items of its class and returns
+// the known state of all of them. A sync which posted changes or failed is kept in the sink.
+func (s *scanner) syncModes(modes models.DiscoveryModes) syncFunc {
+	return func(ctx context.Context, kubernetesAPI *kubernetes.API, kubernetesConfig models.KubernetesConfig, known []models.DiscoveryEvent) (states []models.DiscoveryEvent, err error) {
+		knownItems := knownByClass(known, modes)
+		run := sink.Run{RunId: s.runId, ConfigurationId: kubernetesConfig.ID, Cluster: kubernetesConfig.Cluster, StartedAt: time.Now(), DryRun: s.dryRun}
+		// a sync without changes adds nothing to the copies of the earlier syncs
+		defer func() {
+			if err != nil || len(run.Changes) > 0 {
+				run.Items = states
+				s.writeRun(context.WithoutCancel(ctx), run, err)
+			}
+		}()
+		states = make([]models.DiscoveryEvent, 0, len(known))
+		if modes.Namespaces() {
+			namespaceStates, err := s.syncNamespaces(ctx, kubernetesAPI, kubernetesConfig, knownItems[models.EventClassNamespace], &run)
+			if err != nil {
+				return nil, err
+			}
+			states = append(states, namespaceStates...)
+		}
+		if modes.Workloads() {
+			workloadStates, err := s.syncWorkloads(ctx, kubernetesAPI, kubernetesConfig, knownItems[models.EventClassWorkload], &run)
+			if err != nil {
+				return nil, err
+			}
+			states = append(states, workloadStates...)
+		}
+		return states, nil
+	}
+}
+
+func (s *scanner) syncWorkloads(ctx context.Context, kubernetesAPI *kubernetes.API, kubernetesConfig models.KubernetesConfig, known []models.DiscoveryEvent, run *sink.Run) ([]models.DiscoveryEvent, error) {
+	mapper := workloadMap.NewMapper(kubernetesAPI, kubernetesConfig.Cluster, s.workspaceId, s.runId, kubernetesConfig.CustomWorkloads)
+	nodes, err := kubernetesAPI.Nodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clusterInfo, err := mapper.MapCluster(kubernetesConfig.Cluster, nodes)
+	if err != nil {
+		return nil, err
+	}
+	discoveredWorkloads, err := s.ProcessWorkloads(ctx, mapper, clusterInfo)
+	if err != nil {
+		return nil, err
+	}
+	report, err := s.workloadEventProducer.ProcessWorkloads(ctx, discoveredWorkloads, known, kubernetesConfig.ID)
+	if err != nil {
+		return nil, err
+	}
+	// the known state stays as it is if a batch failed, so the next sync posts its events again
+	err = s.shareProcessReport(ctx, kubernetesConfig.ID, report)
+	if err != nil {
+		return nil, err
+	}
+
+	run.Changes = append(run.Changes, report.Changes...)
+	return s.workloadStates(kubernetesConfig.ID, discoveredWorkloads), nil
+}
+
+func (s *scanner) syncNamespaces(ctx context.Context, kubernetesAPI *kubernetes.API, kubernetesConfig models.KubernetesConfig, known []models.DiscoveryEvent, run *sink.Run) ([]models.DiscoveryEvent, error) {
+	mapper := namespaceMap.NewMapper(kubernetesAPI, kubernetesConfig.Cluster, s.workspaceId, kubernetesConfig.BlackListedNamespaces, s.runId)
+	nodes, err := kubernetesAPI.Nodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clusterDTO, err := mapper.MapCluster(kubernetesConfig.Cluster, nodes)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := kubernetesAPI.Namespaces(ctx, kubernetesConfig.BlackListedNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	discoveredNamespaces, err := s.ProcessNamespace(ctx, kubernetesAPI, mapper, namespaces.Items, clusterDTO)
+	if err != nil {
+		return nil, err
+	}
+	report, err := s.eventProducer.ProcessResults(ctx, discoveredNamespaces, known, kubernetesConfig.ID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.shareProcessReport(ctx, kubernetesConfig.ID, report)
+	if err != nil {
+		return nil, err
+	}
+
+	run.Changes = append(run.Changes, report.Changes...)
+	return s.namespaceStates(kubernetesConfig.ID, discoveredNamespaces), nil
+}
@@ -0,0 +1,113 @@
+package iris
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+// runStatus returns the last run status posted, the admin logs left out
+func (a *fakeIrisApi) runStatus() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	status := ""
+	for _, item := range a.status {
+		if item.Type == "leanix.vsm.item-logged.status" {
+			status = item.Subject
+		}
+	}
+	return status
+}
+
+// watchUntilSynced watches the configuration until the initial sync is done and stops the watch
+// with the cause, it returns the error of the watch
+func watchUntilSynced(t *testing.T, api *fakeIrisApi, client *fake.Clientset, cause error) error {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	getKubernetesAPI := func(*rest.Config) (*kubernetes.API, error) {
+		return &kubernetes.API{Client: client}, nil
+	}
+	done := make(chan error)
+	go func() {
+		done <- newTestScanner(api).Watch(ctx, getKubernetesAPI, nil, "config", time.Hour)
+	}()
+	assert.Eventually(t, func() bool {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		return len(api.posted) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel(cause)
+	return <-done
+}
+
+func TestWatch_stopped(t *testing.T) {
+	setup()
+	api := &fakeIrisApi{
+		configurations: map[string]string{"config": `{"id": "configId", "cluster": "prod", "discoveryMode": "NAMESPACE"}`},
+	}
+	client := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web"}})
+
+	err := watchUntilSynced(t, api, client, context.Canceled)
+
+	assert.NoError(t, err)
+	assert.Equal(t, SUCCESSFUL, api.runStatus())
+}
+
+func TestWatch_leadershipLost(t *testing.T) {
+	setup()
+	api := &fakeIrisApi{
+		configurations: map[string]string{"config": `{"id": "configId", "cluster": "prod", "discoveryMode": "NAMESPACE"}`},
+	}
+	client := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web"}})
+
+	err := watchUntilSynced(t, api, client, kubernetes.ErrLeadershipLost)
+
+	assert.ErrorIs(t, err, kubernetes.ErrLeadershipLost)
+	assert.Equal(t, FAILED, api.runStatus())
+}
+
+func TestStatusOnly(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop-1", ResourceVersion: "1", Labels: map[string]string{"app": "shop"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "shop", Image: "shop:1"}}},
+	}
+	ready := pod.DeepCopy()
+	ready.ResourceVersion = "2"
+	ready.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	patched := ready.DeepCopy()
+	patched.ResourceVersion = "3"
+	patched.Spec.Containers[0].Image = "shop:2"
+	relabelled := ready.DeepCopy()
+	relabelled.ResourceVersion = "3"
+	relabelled.Labels = map[string]string{"app": "shop", "canary": "true"}
+
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "shop", ResourceVersion: "1", Generation: 1}}
+	rolledOut := deployment.DeepCopy()
+	rolledOut.ResourceVersion = "2"
+	rolledOut.Status.ReadyReplicas = 2
+	scaled := rolledOut.DeepCopy()
+	scaled.ResourceVersion = "3"
+	scaled.Generation = 2
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web", ResourceVersion: "1"}}
+	terminating := namespace.DeepCopy()
+	terminating.ResourceVersion = "2"
+	terminating.Status.Phase = corev1.NamespaceTerminating
+
+	assert.True(t, statusOnly(pod, pod))
+	assert.True(t, statusOnly(pod, ready))
+	assert.False(t, statusOnly(ready, patched))
+	assert.False(t, statusOnly(ready, relabelled))
+	assert.True(t, statusOnly(deployment, rolledOut))
+	assert.False(t, statusOnly(rolledOut, scaled))
+	// objects without a generation are synced on every change
+	assert.False(t, statusOnly(namespace, terminating))
+}
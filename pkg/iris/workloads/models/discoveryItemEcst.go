@@ -1,11 +1,58 @@
 package models
 
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/container"
+)
+
 type Data struct {
 	Workload      Workload `json:"workload"`
 	NamespaceName string   `json:"namespaceName"`
-	ServiceName   string   `json:"serviceName"`
-	Cluster       Cluster  `json:"cluster"`
-	Timestamp     string   `json:"timestamp"`
+	// ServiceName is the first of the services, by name
+	ServiceName string `json:"serviceName"`
+	// Services are all services selecting the pods of the workload. Left out for workloads without
+	// services, like the exposures.
+	Services []Service `json:"services,omitempty"`
+	// Exposures are the Ingresses and HTTPRoutes routing to the services of the workload. Left out
+	// for workloads which are not exposed, so their payload stays as posted by earlier versions.
+	Exposures []Exposure `json:"exposures,omitempty"`
+	Cluster   Cluster    `json:"cluster"`
+	Timestamp string     `json:"timestamp"`
+}
+
+type Service struct {
+	Name       string        `json:"name"`
+	Type       string        `json:"type"`
+	ClusterIPs []string      `json:"clusterIPs"`
+	Ports      []ServicePort `json:"ports"`
+}
+
+type ServicePort struct {
+	Name       string `json:"name"`
+	Protocol   string `json:"protocol"`
+	Port       int32  `json:"port"`
+	TargetPort string `json:"targetPort"`
+	NodePort   int32  `json:"nodePort"`
+}
+
+const (
+	ExposureKindIngress   = "ingress"
+	ExposureKindHTTPRoute = "httpRoute"
+)
+
+// Exposure is an Ingress or HTTPRoute routing traffic from outside of the cluster to a workload
+type Exposure struct {
+	Kind  string   `json:"kind"`
+	Name  string   `json:"name"`
+	Hosts []string `json:"hosts"`
+	Paths []string `json:"paths"`
+	// TLSSecretNames are the certificates of an Ingress, HTTPRoutes are terminated by their Gateways
+	TLSSecretNames   []string `json:"tlsSecretNames"`
+	IngressClassName string   `json:"ingressClassName"`
+	// Gateways are the namespaced names of the Gateways an HTTPRoute is attached to
+	Gateways []string `json:"gateways"`
 }
 
 type Workload struct {
@@ -22,12 +69,51 @@ type WorkloadProperties struct {
 	Containers     Containers `json:"containers"`
 }
 
-type Containers struct {
-	Name        string       `json:"name"`
-	Image       string       `json:"image"`
-	Port        interface{}  `json:"port"`
-	K8sLimits   K8sResources `json:"k8sLimits"`
-	K8sRequests K8sResources `json:"k8sRequests"`
+// Containers are all containers of the pod template. Results posted by earlier versions of the
+// connector carry a single container object, which is read as a list with one main container.
+type Containers []Container
+
+type Container struct {
+	Name            string       `json:"name"`
+	Image           string       `json:"image"`
+	ImageRegistry   string       `json:"imageRegistry"`
+	ImageRepository string       `json:"imageRepository"`
+	ImageTag        string       `json:"imageTag"`
+	ImageDigest     string       `json:"imageDigest"`
+	Role            string       `json:"role"`
+	Port            interface{}  `json:"port"`
+	K8sLimits       K8sResources `json:"k8sLimits"`
+	K8sRequests     K8sResources `json:"k8sRequests"`
+	// RunningImages are the images the pods of the workload actually run for this container
+	RunningImages []RunningImage `json:"runningImages"`
+}
+
+// RunningImage is an image ID reported in the pod status. The number of pods running it is left
+// out, it changes with every scaling and rollout step without the workload changing.
+type RunningImage struct {
+	ImageID string `json:"imageId"`
+	Digest  string `json:"digest"`
+}
+
+func (c *Containers) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var single Container
+		if err := json.Unmarshal(trimmed, &single); err != nil {
+			return err
+		}
+		if single.Role == "" {
+			single.Role = container.RoleMain
+		}
+		*c = Containers{single}
+		return nil
+	}
+	var containers []Container
+	if err := json.Unmarshal(trimmed, &containers); err != nil {
+		return err
+	}
+	*c = containers
+	return nil
 }
 
 type K8sResources struct {
@@ -1,62 +1,80 @@
 package events
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"github.com/leanix/leanix-k8s-connector/pkg/diff"
 	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
 	common "github.com/leanix/leanix-k8s-connector/pkg/iris/common/services"
 	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
-	"github.com/pkg/errors"
+	"github.com/leanix/leanix-k8s-connector/pkg/metrics"
+	"github.com/leanix/leanix-k8s-connector/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
 )
 
 type WorkloadEventProducer interface {
-	ProcessWorkloads(data []workload.Data, oldData []models.DiscoveryEvent, configId string) error
-	PostStatus(status []byte) error
-	FilterForChangedItems(newData map[string]workload.Data, oldData map[string]models.DiscoveryEvent, configId string) ([]models.DiscoveryEvent, []models.DiscoveryEvent, map[string]models.DiscoveryEvent, error)
+	ProcessWorkloads(ctx context.Context, data []workload.Data, oldData []models.DiscoveryEvent, configId string) (common.ProcessReport, error)
+	PostStatus(ctx context.Context, status []byte) error
+	FilterForChangedItems(ctx context.Context, newData map[string]workload.Data, oldData map[string]models.DiscoveryEvent, configId string) ([]models.DiscoveryEvent, []models.DiscoveryEvent, map[string]models.DiscoveryEvent, error)
 }
 
 type workloadEventProducer struct {
-	irisApi     common.IrisApi
-	runId       string
-	workspaceId string
+	irisApi       common.IrisApi
+	runId         string
+	workspaceId   string
+	batchConfig   common.BatchConfig
+	deletionGuard common.DeletionGuard
 }
 
-func NewEventWorkloadProducer(irisApi common.IrisApi, runId string, workspaceId string) WorkloadEventProducer {
+func NewEventWorkloadProducer(irisApi common.IrisApi, runId string, workspaceId string, batchConfig common.BatchConfig, deletionGuard common.DeletionGuard) WorkloadEventProducer {
 	return &workloadEventProducer{
-		irisApi:     irisApi,
-		runId:       runId,
-		workspaceId: workspaceId,
+		irisApi:       irisApi,
+		runId:         runId,
+		workspaceId:   workspaceId,
+		batchConfig:   batchConfig,
+		deletionGuard: deletionGuard,
 	}
 }
 
-func (p *workloadEventProducer) ProcessWorkloads(data []workload.Data, oldData []models.DiscoveryEvent, configId string) error {
-	created, updated, deleted, err := p.CreateECSTWorkloadEvents(data, oldData, configId)
+// ProcessWorkloads posts the created, updated and deleted events in batches, see common.PostEcstBatches.
+// Nothing is posted if the deleted events exceed the limits of the deletion guard.
+func (p *workloadEventProducer) ProcessWorkloads(ctx context.Context, data []workload.Data, oldData []models.DiscoveryEvent, configId string) (common.ProcessReport, error) {
+	started := time.Now()
+	created, updated, deleted, err := p.CreateECSTWorkloadEvents(ctx, data, oldData, configId)
+	metrics.ObservePhase(metrics.PhaseDiffing, time.Since(started))
 	if err != nil {
-		return err
+		return common.ProcessReport{}, err
+	}
+	err = p.deletionGuard.CheckClasses(deleted, oldData)
+	if err != nil {
+		return common.ProcessReport{}, err
 	}
 	ecstEvents := append(created, updated...)
 	ecstEvents = append(ecstEvents, deleted...)
 	if len(ecstEvents) == 0 {
-		return nil
+		return common.ProcessReport{Batches: common.BatchReport{}, Updated: updated, Changes: ecstEvents}, nil
 	}
-	scannedEcstObjectsByte, err := json.Marshal(ecstEvents)
+	started = time.Now()
+	batches, err := common.PostEcstBatches(ctx, p.irisApi, ecstEvents, p.batchConfig)
+	metrics.ObservePhase(metrics.PhasePosting, time.Since(started))
 	if err != nil {
-		return errors.Wrap(err, "Marshall scanned ECST services")
+		return common.ProcessReport{}, err
 	}
-
-	return p.irisApi.PostEcstResults(scannedEcstObjectsByte)
+	return common.ProcessReport{Batches: batches, Updated: updated, Changes: ecstEvents}, nil
 }
 
-func (p *workloadEventProducer) PostStatus(status []byte) error {
-	return p.irisApi.PostStatus(status)
+func (p *workloadEventProducer) PostStatus(ctx context.Context, status []byte) error {
+	return p.irisApi.PostStatus(ctx, status)
 }
 
-func (p *workloadEventProducer) CreateECSTWorkloadEvents(data []workload.Data, oldData []models.DiscoveryEvent, configId string) ([]models.DiscoveryEvent, []models.DiscoveryEvent, []models.DiscoveryEvent, error) {
+func (p *workloadEventProducer) CreateECSTWorkloadEvents(ctx context.Context, data []workload.Data, oldData []models.DiscoveryEvent, configId string) ([]models.DiscoveryEvent, []models.DiscoveryEvent, []models.DiscoveryEvent, error) {
 	resultMap := p.createItemMap(data, configId)
 	oldResultMap := p.createOldItemMap(oldData)
 
-	createdEvents, updatedEvents, oldResultMap, err := p.FilterForChangedItems(resultMap, oldResultMap, configId)
+	createdEvents, updatedEvents, oldResultMap, err := p.FilterForChangedItems(ctx, resultMap, oldResultMap, configId)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -99,7 +117,9 @@ func GenerateHashWorkload(toBeHashed interface{}) (string, error) {
 
 }
 
-func (p *workloadEventProducer) FilterForChangedItems(newData map[string]workload.Data, oldData map[string]models.DiscoveryEvent, configId string) ([]models.DiscoveryEvent, []models.DiscoveryEvent, map[string]models.DiscoveryEvent, error) {
+func (p *workloadEventProducer) FilterForChangedItems(ctx context.Context, newData map[string]workload.Data, oldData map[string]models.DiscoveryEvent, configId string) ([]models.DiscoveryEvent, []models.DiscoveryEvent, map[string]models.DiscoveryEvent, error) {
+	_, span := tracing.Start(ctx, "FilterForChangedItems", attribute.Int("items.new", len(newData)), attribute.Int("items.old", len(oldData)))
+	defer span.End()
 	updated := make([]models.DiscoveryEvent, 0)
 	created := make([]models.DiscoveryEvent, 0)
 	for id, newItem := range newData {
@@ -109,7 +129,13 @@ func (p *workloadEventProducer) FilterForChangedItems(newData map[string]workloa
 			created = append(created, createdEcstDiscoveryEvent)
 			// if item has been discovered before, check if there are any changes in the new payload
 		} else {
-			oldItemHash, err := GenerateHashWorkload(oldItem.Body.State.Data)
+			// Parse the old payload into the current model first, so results posted with an older
+			// shape of the model are compared field by field instead of by their raw JSON
+			oldItemData, err := common.ParseWorkloadData(oldItem)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			oldItemHash, err := GenerateHashWorkload(oldItemData)
 			if err != nil {
 				return nil, nil, nil, err
 			}
@@ -119,7 +145,13 @@ func (p *workloadEventProducer) FilterForChangedItems(newData map[string]workloa
 			}
 
 			if oldItemHash != newItemHash {
-				updated = append(updated, workload.CreateEcstDiscoveryEvent(models.EventTypeChange, models.EventActionUpdated, id, newItem, p.runId, p.workspaceId, configId))
+				changes, err := diff.Compare(oldItemData, newItem)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				updatedEvent := workload.CreateEcstDiscoveryEvent(models.EventTypeChange, models.EventActionUpdated, id, newItem, p.runId, p.workspaceId, configId)
+				updatedEvent.Body.Changes = changes
+				updated = append(updated, updatedEvent)
 			}
 			// Remove key from oldData results, so we only have the entries inside which shall be deleted
 			delete(oldData, id)
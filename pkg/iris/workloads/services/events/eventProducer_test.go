@@ -1,16 +1,19 @@
 package events
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"testing"
 
+	"github.com/leanix/leanix-k8s-connector/pkg/diff"
 	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
 	common "github.com/leanix/leanix-k8s-connector/pkg/iris/common/services"
 	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
 	"github.com/leanix/leanix-k8s-connector/pkg/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func Test_eventProducer_filter_created(t *testing.T) {
@@ -28,9 +31,11 @@ func Test_eventProducer_filter_created(t *testing.T) {
 					Replicas:       "1",
 					UpdateStrategy: "rollback",
 					Containers: workload.Containers{
-						Name:  "testContainer1",
-						Image: "testImage1",
-						Port:  "8080",
+						{
+							Name:  "testContainer1",
+							Image: "testImage1",
+							Port:  "8080",
+						},
 					},
 				},
 			},
@@ -64,9 +69,11 @@ func Test_eventProducer_filter_created(t *testing.T) {
 								Replicas:       "1",
 								UpdateStrategy: "rollback",
 								Containers: workload.Containers{
-									Name:  "testContainer1",
-									Image: "testImage1",
-									Port:  "8080",
+									{
+										Name:  "testContainer1",
+										Image: "testImage1",
+										Port:  "8080",
+									},
 								},
 							},
 						},
@@ -83,8 +90,8 @@ func Test_eventProducer_filter_created(t *testing.T) {
 		},
 	}
 	//oldData map[string]models.DiscoveryEvent
-	p := NewEventWorkloadProducer(mockApi, "testRunId", "testWorkspaceId")
-	created, updated, _, err := p.FilterForChangedItems(newWorkload, oldData, "testConfigId")
+	p := NewEventWorkloadProducer(mockApi, "testRunId", "testWorkspaceId", common.DefaultBatchConfig, common.DefaultDeletionGuard)
+	created, updated, _, err := p.FilterForChangedItems(context.Background(), newWorkload, oldData, "testConfigId")
 
 	assert.NoError(t, err)
 	assert.Empty(t, updated)
@@ -107,9 +114,11 @@ func Test_eventProducer_filter_updated_no_change(t *testing.T) {
 					Replicas:       "1",
 					UpdateStrategy: "rollback",
 					Containers: workload.Containers{
-						Name:  "testContainer1",
-						Image: "testImage1",
-						Port:  "8080",
+						{
+							Name:  "testContainer1",
+							Image: "testImage1",
+							Port:  "8080",
+						},
 					},
 				},
 			},
@@ -141,9 +150,11 @@ func Test_eventProducer_filter_updated_no_change(t *testing.T) {
 								Replicas:       "1",
 								UpdateStrategy: "rollback",
 								Containers: workload.Containers{
-									Name:  "testContainer1",
-									Image: "testImage1",
-									Port:  "8080",
+									{
+										Name:  "testContainer1",
+										Image: "testImage1",
+										Port:  "8080",
+									},
 								},
 							},
 						},
@@ -161,8 +172,8 @@ func Test_eventProducer_filter_updated_no_change(t *testing.T) {
 	}
 
 	//oldData map[string]models.DiscoveryEvent
-	p := NewEventWorkloadProducer(mockApi, "testRunId", "testWorkspaceId")
-	created, updated, filteredData, err := p.FilterForChangedItems(newData, oldData, "testConfigId")
+	p := NewEventWorkloadProducer(mockApi, "testRunId", "testWorkspaceId", common.DefaultBatchConfig, common.DefaultDeletionGuard)
+	created, updated, filteredData, err := p.FilterForChangedItems(context.Background(), newData, oldData, "testConfigId")
 
 	assert.NoError(t, err)
 	assert.Empty(t, updated)
@@ -187,9 +198,11 @@ func Test_eventProducer_filter_updated_changed(t *testing.T) {
 					Replicas:       "1",
 					UpdateStrategy: "rollback",
 					Containers: workload.Containers{
-						Name:  "testContainer1",
-						Image: "testImage1",
-						Port:  "8080",
+						{
+							Name:  "testContainer1",
+							Image: "testImage1",
+							Port:  "8080",
+						},
 					},
 				},
 			},
@@ -222,9 +235,11 @@ func Test_eventProducer_filter_updated_changed(t *testing.T) {
 								Replicas:       "1",
 								UpdateStrategy: "rollback",
 								Containers: workload.Containers{
-									Name:  "testContainer1",
-									Image: "testImage1",
-									Port:  "8080",
+									{
+										Name:  "testContainer1",
+										Image: "testImage1",
+										Port:  "8080",
+									},
 								},
 							},
 						},
@@ -245,15 +260,20 @@ func Test_eventProducer_filter_updated_changed(t *testing.T) {
 		runId:       "testRunId",
 		workspaceId: "testWorkspaceId",
 	}
-	created, updated, filteredData, err := p.FilterForChangedItems(newData, oldData, "testConfigId")
+	created, updated, filteredData, err := p.FilterForChangedItems(context.Background(), newData, oldData, "testConfigId")
 
 	assert.NoError(t, err)
 	parsedData, err := common.ParseWorkloadData(updated[0])
 	assert.NoError(t, err)
 	assert.Empty(t, created)
 	assert.Len(t, updated, 1)
-	assert.Equal(t, "testImage1", parsedData.Workload.WorkloadProperties.Containers.Image)
+	assert.Equal(t, "testImage1", parsedData.Workload.WorkloadProperties.Containers[0].Image)
 	assert.Empty(t, filteredData)
+	assert.Equal(t, []diff.Change{
+		{Path: "cluster.name", Kind: diff.KindChanged, Old: "testCluster1", New: "testClusterName1"},
+		{Path: "cluster.os", Kind: diff.KindChanged, Old: "", New: "linux"},
+		{Path: "namespaceName", Kind: diff.KindChanged, Old: "namespaceName1", New: "namespace1"},
+	}, updated[0].Body.Changes)
 }
 
 func Test_eventProducer_createECSTEvents(t *testing.T) {
@@ -276,9 +296,11 @@ func Test_eventProducer_createECSTEvents(t *testing.T) {
 					Replicas:       "1",
 					UpdateStrategy: "rollback",
 					Containers: workload.Containers{
-						Name:  "testContainer1",
-						Image: "testImage1",
-						Port:  "8080",
+						{
+							Name:  "testContainer1",
+							Image: "testImage1",
+							Port:  "8080",
+						},
 					},
 				},
 			},
@@ -302,9 +324,11 @@ func Test_eventProducer_createECSTEvents(t *testing.T) {
 					Replicas:       "1",
 					UpdateStrategy: "rollback",
 					Containers: workload.Containers{
-						Name:  "testContainer2",
-						Image: "testImage2",
-						Port:  "8080",
+						{
+							Name:  "testContainer2",
+							Image: "testImage2",
+							Port:  "8080",
+						},
 					},
 				},
 			},
@@ -328,9 +352,11 @@ func Test_eventProducer_createECSTEvents(t *testing.T) {
 					Replicas:       "1",
 					UpdateStrategy: "rollback",
 					Containers: workload.Containers{
-						Name:  "testContainer3",
-						Image: "testImage3",
-						Port:  "8080",
+						{
+							Name:  "testContainer3",
+							Image: "testImage3",
+							Port:  "8080",
+						},
 					},
 				},
 			},
@@ -367,9 +393,11 @@ func Test_eventProducer_createECSTEvents(t *testing.T) {
 								Replicas:       "1",
 								UpdateStrategy: "rollback",
 								Containers: workload.Containers{
-									Name:  "testContainer1",
-									Image: "testImage1",
-									Port:  "8080",
+									{
+										Name:  "testContainer1",
+										Image: "testImage1",
+										Port:  "8080",
+									},
 								},
 							},
 						},
@@ -407,9 +435,11 @@ func Test_eventProducer_createECSTEvents(t *testing.T) {
 								Replicas:       "1",
 								UpdateStrategy: "rollback",
 								Containers: workload.Containers{
-									Name:  "testContainer2",
-									Image: "testImage2",
-									Port:  "8080",
+									{
+										Name:  "testContainer2",
+										Image: "testImage2",
+										Port:  "8080",
+									},
 								},
 							},
 						},
@@ -447,9 +477,11 @@ func Test_eventProducer_createECSTEvents(t *testing.T) {
 								Replicas:       "1",
 								UpdateStrategy: "rollback",
 								Containers: workload.Containers{
-									Name:  "testContainer3",
-									Image: "testImage3",
-									Port:  "8080",
+									{
+										Name:  "testContainer3",
+										Image: "testImage3",
+										Port:  "8080",
+									},
 								},
 							},
 						},
@@ -471,7 +503,7 @@ func Test_eventProducer_createECSTEvents(t *testing.T) {
 		runId:       "testRunId",
 		workspaceId: "testWorkspaceId",
 	}
-	created, updated, deleted, err := p.CreateECSTWorkloadEvents(newData, oldData, "testConfigId")
+	created, updated, deleted, err := p.CreateECSTWorkloadEvents(context.Background(), newData, oldData, "testConfigId")
 
 	assert.NoError(t, err)
 	assert.Len(t, created, 1)
@@ -502,8 +534,118 @@ func Test_eventProducer_processECSTResults_empty(t *testing.T) {
 
 	var newData []workload.Data
 	var oldData []models.DiscoveryEvent
-	p := NewEventWorkloadProducer(mockApi, "testRunId", "testWorkspaceId")
-	err := p.ProcessWorkloads(newData, oldData, "testConfigId")
+	p := NewEventWorkloadProducer(mockApi, "testRunId", "testWorkspaceId", common.DefaultBatchConfig, common.DefaultDeletionGuard)
+	report, err := p.ProcessWorkloads(context.Background(), newData, oldData, "testConfigId")
+
+	assert.NoError(t, err)
+	assert.Empty(t, report.Batches)
+	assert.Empty(t, report.Updated)
+}
+
+func Test_eventProducer_filter_updated_legacy_containers(t *testing.T) {
+	mockApi := mocks.NewIrisApi(t)
+	newData := map[string]workload.Data{
+		"testId1": {
+			Workload: workload.Workload{
+				Name:         "testWorkload1",
+				WorkloadType: "deployment",
+				WorkloadProperties: workload.WorkloadProperties{
+					Containers: workload.Containers{
+						{
+							Name:  "testContainer1",
+							Image: "testImage1",
+							Role:  "main",
+						},
+					},
+				},
+			},
+			Cluster: workload.Cluster{
+				Name: "testCluster1",
+			},
+			NamespaceName: "namespaceName1",
+		}}
+	// results posted by earlier versions of the connector carry a single container object
+	oldData := map[string]models.DiscoveryEvent{
+		"testId1": {
+			Body: models.DiscoveryBody{
+				State: models.State{
+					Data: map[string]interface{}{
+						"workload": map[string]interface{}{
+							"name": "testWorkload1",
+							"type": "deployment",
+							"workloadProperties": map[string]interface{}{
+								"containers": map[string]interface{}{
+									"name":  "testContainer1",
+									"image": "testImage1",
+								},
+							},
+						},
+						"cluster": map[string]interface{}{
+							"name": "testCluster1",
+						},
+						"namespaceName": "namespaceName1",
+					},
+				},
+			},
+		},
+	}
+
+	p := NewEventWorkloadProducer(mockApi, "testRunId", "testWorkspaceId", common.DefaultBatchConfig, common.DefaultDeletionGuard)
+	created, updated, filteredData, err := p.FilterForChangedItems(context.Background(), newData, oldData, "testConfigId")
+
+	assert.NoError(t, err)
+	assert.Empty(t, created)
+	assert.Empty(t, updated)
+	assert.Empty(t, filteredData)
+}
+
+func knownWorkloads(n int) []models.DiscoveryEvent {
+	known := make([]models.DiscoveryEvent, 0, n)
+	for i := 0; i < n; i++ {
+		data := workload.Data{
+			Workload:      workload.Workload{Name: fmt.Sprintf("testWorkload%d", i), WorkloadType: "deployment"},
+			Cluster:       workload.Cluster{Name: "testCluster"},
+			NamespaceName: "namespaceName",
+		}
+		known = append(known, workload.CreateEcstDiscoveryEvent(models.EVENT_TYPE_STATE, "", fmt.Sprintf("testId%d", i), data, "testRunId", "testWorkspaceId", "testConfigId"))
+	}
+	return known
+}
+
+func Test_eventProducer_processECSTResults_massDeletion(t *testing.T) {
+	mockApi := mocks.NewIrisApi(t)
+
+	// an empty discovery would delete every known workload, nothing is posted
+	p := NewEventWorkloadProducer(mockApi, "testRunId", "testWorkspaceId", common.DefaultBatchConfig, common.DefaultDeletionGuard)
+	_, err := p.ProcessWorkloads(context.Background(), []workload.Data{}, knownWorkloads(20), "testConfigId")
+
+	var massDeletion *common.MassDeletionError
+	assert.ErrorAs(t, err, &massDeletion)
+	assert.Equal(t, 20, massDeletion.Deleted)
+	assert.Equal(t, 20, massDeletion.Known)
+}
+
+func Test_eventProducer_processECSTResults_allowMassDeletion(t *testing.T) {
+	mockApi := mocks.NewIrisApi(t)
+	mockApi.EXPECT().PostEcstResults(mock.Anything, mock.Anything).Return(nil).Once()
+
+	guard := common.DefaultDeletionGuard
+	guard.AllowMassDeletion = true
+	p := NewEventWorkloadProducer(mockApi, "testRunId", "testWorkspaceId", common.DefaultBatchConfig, guard)
+	report, err := p.ProcessWorkloads(context.Background(), []workload.Data{}, knownWorkloads(20), "testConfigId")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 20, report.Batches.Events())
+}
+
+func Test_eventProducer_processECSTResults_smallInventory(t *testing.T) {
+	mockApi := mocks.NewIrisApi(t)
+	mockApi.EXPECT().PostEcstResults(mock.Anything, mock.Anything).Return(nil).Once()
+
+	// deleting the only workload of a small inventory is ordinary churn
+	p := NewEventWorkloadProducer(mockApi, "testRunId", "testWorkspaceId", common.DefaultBatchConfig, common.DefaultDeletionGuard)
+	report, err := p.ProcessWorkloads(context.Background(), []workload.Data{}, knownWorkloads(1), "testConfigId")
 
 	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Batches.Events())
 }
@@ -0,0 +1,29 @@
+package mapper
+
+import (
+	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+	v1 "k8s.io/api/core/v1"
+)
+
+// MapContainers maps every container of the pod template in canonical order, see
+// kubernetes.PodContainers for the roles
+func MapContainers(template v1.PodTemplateSpec) workload.Containers {
+	podContainers := kubernetes.CanonicalOrder(kubernetes.PodContainers(template))
+	containers := make(workload.Containers, 0, len(podContainers))
+	for _, c := range podContainers {
+		containers = append(containers, workload.Container{
+			Name:            c.Name,
+			Image:           c.Image,
+			ImageRegistry:   c.ImageReference.Registry,
+			ImageRepository: c.ImageReference.Repository,
+			ImageTag:        c.ImageReference.Tag,
+			ImageDigest:     c.ImageReference.Digest,
+			Role:            c.Role,
+			Port:            c.Ports,
+			K8sLimits:       CreateK8sResources(c.Resources.Limits),
+			K8sRequests:     CreateK8sResources(c.Resources.Requests),
+		})
+	}
+	return containers
+}
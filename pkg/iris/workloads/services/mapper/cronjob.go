@@ -1,24 +1,22 @@
 package mapper
 
 import (
-	"reflect"
-	"strings"
 	"time"
 
 	"github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
-	"github.com/leanix/leanix-k8s-connector/pkg/logger"
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
 	batchv1 "k8s.io/api/batch/v1"
-	_ "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
 )
 
-func (m *workloadMapper) MapCronJobsEcst(cluster models.Cluster, cronJobs *batchv1.CronJobList, services *v1.ServiceList) ([]models.Data, error) {
+func (m *workloadMapper) MapCronJobsEcst(cluster models.Cluster, cronJobs *batchv1.CronJobList, services *v1.ServiceList, pods *OwnedPods) ([]models.Data, error) {
 	var groupedCronJobs []models.Data
 
 	for _, cronJob := range cronJobs.Items {
-		// Check if any service has the exact same selector labels and use this as the service related to the deployment
-		cronJobService := ResolveK8sServiceForK8sCronJob(services, cronJob)
-		mappedCronJob := m.CreateCronjobEcst(cluster, cronJob, cronJobService)
+		cronJobServices := kubernetes.SelectingServices(services, cronJob.Namespace, cronJob.Spec.JobTemplate.Spec.Template)
+		mappedCronJob := m.CreateCronjobEcst(cluster, cronJob, serviceName(cronJobServices))
+		mappedCronJob.Services = MapServices(cronJobServices)
+		AddRunningImages(mappedCronJob.Workload.WorkloadProperties.Containers, pods.Of(&cronJob))
 		groupedCronJobs = append(groupedCronJobs, mappedCronJob)
 	}
 
@@ -33,14 +31,8 @@ func (m *workloadMapper) CreateCronjobEcst(cluster models.Cluster, cronJob batch
 			WorkloadType: "cronjob",
 			Labels:       cronJob.ObjectMeta.Labels,
 			WorkloadProperties: models.WorkloadProperties{
-				Schedule: cronJob.Spec.Schedule,
-				Containers: models.Containers{
-					Name:        cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Name,
-					Image:       strings.Split(cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image, ":")[0],
-					Port:        cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Ports,
-					K8sLimits:   CreateK8sResources(cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Resources.Limits),
-					K8sRequests: CreateK8sResources(cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Resources.Requests),
-				},
+				Schedule:   cronJob.Spec.Schedule,
+				Containers: MapContainers(cronJob.Spec.JobTemplate.Spec.Template),
 			},
 		},
 		Cluster: models.Cluster{
@@ -55,27 +47,3 @@ func (m *workloadMapper) CreateCronjobEcst(cluster models.Cluster, cronJob batch
 	}
 	return mappedCronjob
 }
-
-func ResolveK8sServiceForK8sCronJob(services *v1.ServiceList, cronJob batchv1.CronJob) string {
-	cronJobService := ""
-	if cronJob.Spec.JobTemplate.Spec.Selector != nil {
-		for _, service := range services.Items {
-			sharedLabelsCronJob := map[string]string{}
-			sharedLabelsService := map[string]string{}
-
-			for label := range service.Spec.Selector {
-				if _, ok := cronJob.Spec.JobTemplate.Spec.Selector.MatchLabels[label]; ok {
-					sharedLabelsCronJob[label] = cronJob.Spec.JobTemplate.Spec.Selector.MatchLabels[label]
-					sharedLabelsService[label] = service.Spec.Selector[label]
-				}
-			}
-			if len(sharedLabelsCronJob) != 0 && len(sharedLabelsService) != 0 && reflect.DeepEqual(sharedLabelsCronJob, sharedLabelsService) {
-				cronJobService = service.Name
-				break
-			}
-		}
-	} else {
-		logger.Infof("CronJob %s has no selector labels", cronJob.Name)
-	}
-	return cronJobService
-}
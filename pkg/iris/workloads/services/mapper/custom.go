@@ -0,0 +1,216 @@
+package mapper
+
+import (
+	"context"
+	"fmt"
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// customWorkloadPaths are the parsed JSONPath expressions of a custom workload, nil for the ones
+// left empty
+type customWorkloadPaths struct {
+	name        *jsonpath.JSONPath
+	replicas    *jsonpath.JSONPath
+	podTemplate *jsonpath.JSONPath
+	images      *jsonpath.JSONPath
+}
+
+// MapCustomWorkloadsEcst maps the objects of the custom resources declared in the configuration.
+func (m *workloadMapper) MapCustomWorkloadsEcst(ctx context.Context, cluster workload.Cluster, services *v1.ServiceList, pods *OwnedPods) ([]workload.Data, error) {
+	var allCustomWorkloads []workload.Data
+
+	for _, customWorkload := range m.customWorkloads {
+		paths, err := parseCustomWorkload(customWorkload)
+		if err != nil {
+			return nil, err
+		}
+		resource := schema.GroupVersionResource{Group: customWorkload.Group, Version: customWorkload.Version, Resource: customWorkload.Resource}
+		objects, err := m.KubernetesApi.CustomResources(ctx, resource, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list custom workloads %s: %w", resource, err)
+		}
+		for i := range objects.Items {
+			object := &objects.Items[i]
+			template, err := customPodTemplate(paths, object)
+			if err != nil {
+				return nil, fmt.Errorf("failed to map custom workload %s %s/%s: %w", resource, object.GetNamespace(), object.GetName(), err)
+			}
+			customServices := kubernetes.SelectingServices(services, object.GetNamespace(), template)
+			mappedCustomWorkload, err := m.CreateCustomWorkloadEcst(cluster, customWorkload, paths, object, template, serviceName(customServices))
+			if err != nil {
+				return nil, fmt.Errorf("failed to map custom workload %s %s/%s: %w", resource, object.GetNamespace(), object.GetName(), err)
+			}
+			mappedCustomWorkload.Services = MapServices(customServices)
+			AddRunningImages(mappedCustomWorkload.Workload.WorkloadProperties.Containers, pods.Of(object))
+			allCustomWorkloads = append(allCustomWorkloads, mappedCustomWorkload)
+		}
+	}
+
+	return allCustomWorkloads, nil
+}
+
+// CreateCustomWorkloadEcst create a data object that contains name, labels, replicas, containers and
+// more of a custom resource object
+func (m *workloadMapper) CreateCustomWorkloadEcst(cluster workload.Cluster, customWorkload models.CustomWorkload, paths customWorkloadPaths, object *unstructured.Unstructured, template v1.PodTemplateSpec, service string) (workload.Data, error) {
+	name, err := findString(paths.name, object)
+	if err != nil {
+		return workload.Data{}, err
+	}
+	if name == "" {
+		name = object.GetName()
+	}
+	replicas, err := findString(paths.replicas, object)
+	if err != nil {
+		return workload.Data{}, err
+	}
+	workloadType := customWorkload.WorkloadType
+	if workloadType == "" {
+		workloadType = lowerFirst(object.GetKind())
+	}
+	creationTimestamp := object.GetCreationTimestamp()
+	return workload.Data{
+		Workload: workload.Workload{
+			Name:         name,
+			WorkloadType: workloadType,
+			Labels:       object.GetLabels(),
+			WorkloadProperties: workload.WorkloadProperties{
+				Replicas:   replicas,
+				Containers: MapContainers(template),
+			},
+		},
+		Cluster: workload.Cluster{
+			Name:       cluster.Name,
+			OsImage:    cluster.OsImage,
+			NoOfNodes:  cluster.NoOfNodes,
+			K8sVersion: cluster.K8sVersion,
+		},
+		ServiceName:   service,
+		NamespaceName: object.GetNamespace(),
+		Timestamp:     creationTimestamp.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+func parseCustomWorkload(customWorkload models.CustomWorkload) (customWorkloadPaths, error) {
+	var paths customWorkloadPaths
+	var err error
+	for _, p := range []struct {
+		field      string
+		expression string
+		path       **jsonpath.JSONPath
+	}{
+		{"name", customWorkload.Name, &paths.name},
+		{"replicas", customWorkload.Replicas, &paths.replicas},
+		{"podTemplate", customWorkload.PodTemplate, &paths.podTemplate},
+		{"images", customWorkload.Images, &paths.images},
+	} {
+		*p.path, err = parseJSONPath(p.field, p.expression)
+		if err != nil {
+			return customWorkloadPaths{}, fmt.Errorf("invalid %s JSONPath of custom workload %s.%s: %w", p.field, customWorkload.Resource, customWorkload.Group, err)
+		}
+	}
+	return paths, nil
+}
+
+func parseJSONPath(field string, expression string) (*jsonpath.JSONPath, error) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(expression, "{") {
+		expression = "{" + expression + "}"
+	}
+	path := jsonpath.New(field).AllowMissingKeys(true)
+	return path, path.Parse(expression)
+}
+
+// customPodTemplate reads the pod template of the object, or a template with a container for every
+// image if there is no pod template
+func customPodTemplate(paths customWorkloadPaths, object *unstructured.Unstructured) (v1.PodTemplateSpec, error) {
+	var template v1.PodTemplateSpec
+	values, err := findValues(paths.podTemplate, object)
+	if err != nil {
+		return template, err
+	}
+	if len(values) > 0 {
+		content, ok := values[0].(map[string]interface{})
+		if !ok {
+			return template, fmt.Errorf("pod template is a %T, not an object", values[0])
+		}
+		return template, runtime.DefaultUnstructuredConverter.FromUnstructured(content, &template)
+	}
+
+	values, err = findValues(paths.images, object)
+	if err != nil {
+		return template, err
+	}
+	images := map[string]bool{}
+	for _, value := range values {
+		switch value := value.(type) {
+		case string:
+			images[value] = true
+		case []interface{}:
+			for _, item := range value {
+				if image, ok := item.(string); ok {
+					images[image] = true
+				}
+			}
+		}
+	}
+	for image := range images {
+		template.Spec.Containers = append(template.Spec.Containers, v1.Container{Name: image, Image: image})
+	}
+	sort.Slice(template.Spec.Containers, func(a, b int) bool {
+		return template.Spec.Containers[a].Name < template.Spec.Containers[b].Name
+	})
+	return template, nil
+}
+
+// findValues returns all values the path selects in the object, none for an empty path
+func findValues(path *jsonpath.JSONPath, object *unstructured.Unstructured) ([]interface{}, error) {
+	if path == nil {
+		return nil, nil
+	}
+	results, err := path.FindResults(object.Object)
+	if err != nil {
+		return nil, err
+	}
+	var values []interface{}
+	for _, result := range results {
+		for _, value := range result {
+			if value.IsValid() && value.CanInterface() {
+				values = append(values, value.Interface())
+			}
+		}
+	}
+	return values, nil
+}
+
+// findString returns the first value the path selects in the object as string
+func findString(path *jsonpath.JSONPath, object *unstructured.Unstructured) (string, error) {
+	values, err := findValues(path, object)
+	if err != nil || len(values) == 0 || values[0] == nil {
+		return "", err
+	}
+	return fmt.Sprint(values[0]), nil
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	runes := []rune(s)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,129 @@
+package mapper
+
+import (
+	"context"
+	"testing"
+
+	commonModels "github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+	"github.com/leanix/leanix-k8s-connector/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/utils/pointer"
+)
+
+var (
+	rollouts = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}
+	mlModels = schema.GroupVersionResource{Group: "ml.example.com", Version: "v1", Resource: "models"}
+)
+
+func customObject(apiVersion string, kind string, name string, uid string, spec map[string]interface{}, status map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name, "namespace": "test", "uid": uid, "labels": map[string]interface{}{"team": "shop"}},
+		"spec":       spec,
+		"status":     status,
+	}}
+}
+
+// newDynamicClient serves the custom resources, HTTPRoutes and ReferenceGrants
+func newDynamicClient(listKinds map[schema.GroupVersionResource]string, objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	listKinds[kubernetes.HTTPRouteResource] = "HTTPRouteList"
+	listKinds[kubernetes.ReferenceGrantResource] = "ReferenceGrantList"
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+}
+
+func Test_MapWorkloads_customWorkloads(t *testing.T) {
+	logger.Init()
+	rollout := customObject("argoproj.io/v1alpha1", "Rollout", "shop", "rollout-uid", map[string]interface{}{
+		"template": map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "shop"}},
+			"spec": map[string]interface{}{"containers": []interface{}{
+				map[string]interface{}{"name": "app", "image": "registry.example.com/shop:1.2.0"},
+			}},
+		},
+	}, map[string]interface{}{"replicas": int64(3)})
+	model := customObject("ml.example.com/v1", "Model", "ranking", "model-uid", map[string]interface{}{
+		"server":    map[string]interface{}{"image": "ghcr.io/example/serve:2.0"},
+		"sidecars":  []interface{}{"ghcr.io/example/metrics:1.0", "ghcr.io/example/serve:2.0"},
+		"modelName": "ranking-v7",
+	}, nil)
+	dynamicClient := newDynamicClient(map[schema.GroupVersionResource]string{rollouts: "RolloutList", mlModels: "ModelList"}, rollout, model)
+	rolloutOwner := []metav1.OwnerReference{{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "shop", UID: "rollout-uid", Controller: pointer.Bool(true)}}
+	client := fake.NewSimpleClientset(
+		// the ReplicaSets of a Rollout are part of the Rollout
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "shop-6c9f", Namespace: "test", UID: "rs-uid", OwnerReferences: rolloutOwner}},
+		runningPod("shop-6c9f-a", controlledBy("ReplicaSet", "shop-6c9f", "rs-uid"), map[string]string{"app": digestV1}),
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "test"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "shop"}},
+		},
+	)
+	customWorkloads := []commonModels.CustomWorkload{
+		{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts", Replicas: "{.status.replicas}", PodTemplate: ".spec.template"},
+		{Group: "ml.example.com", Version: "v1", Resource: "models", WorkloadType: "inferenceModel", Name: ".spec.modelName", Images: "{.spec.server.image}{.spec.sidecars}"},
+	}
+	mapper := NewMapper(&kubernetes.API{Client: client, Dynamic: dynamicClient}, "testCluster", "testWorkspace", "testRunId", customWorkloads)
+
+	results, err := mapper.MapWorkloads(context.Background(), models.Cluster{Name: "testCluster"})
+
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	mappedRollout := results[0]
+	assert.Equal(t, "rollout", mappedRollout.Workload.WorkloadType)
+	assert.Equal(t, "shop", mappedRollout.Workload.Name)
+	assert.Equal(t, "3", mappedRollout.Workload.WorkloadProperties.Replicas)
+	assert.Equal(t, map[string]string{"team": "shop"}, mappedRollout.Workload.Labels)
+	assert.Equal(t, "shop", mappedRollout.ServiceName)
+	assert.Len(t, mappedRollout.Workload.WorkloadProperties.Containers, 1)
+	container := mappedRollout.Workload.WorkloadProperties.Containers[0]
+	assert.Equal(t, "registry.example.com", container.ImageRegistry)
+	assert.Equal(t, "1.2.0", container.ImageTag)
+	assert.Equal(t, []models.RunningImage{{ImageID: digestV1, Digest: digestV1}}, container.RunningImages)
+
+	mappedModel := results[1]
+	assert.Equal(t, "inferenceModel", mappedModel.Workload.WorkloadType)
+	assert.Equal(t, "ranking-v7", mappedModel.Workload.Name)
+	assert.Empty(t, mappedModel.Workload.WorkloadProperties.Replicas)
+	images := []string{}
+	for _, c := range mappedModel.Workload.WorkloadProperties.Containers {
+		images = append(images, c.Image)
+	}
+	assert.Equal(t, []string{"ghcr.io/example/metrics:1.0", "ghcr.io/example/serve:2.0"}, images)
+}
+
+func Test_MapWorkloads_customWorkloadsInvalidJSONPath(t *testing.T) {
+	logger.Init()
+	customWorkloads := []commonModels.CustomWorkload{{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts", Replicas: "{.status.replicas"}}
+	mapper := NewMapper(&kubernetes.API{Client: fake.NewSimpleClientset()}, "testCluster", "testWorkspace", "testRunId", customWorkloads)
+
+	_, err := mapper.MapWorkloads(context.Background(), models.Cluster{Name: "testCluster"})
+
+	assert.ErrorContains(t, err, "invalid replicas JSONPath of custom workload rollouts.argoproj.io")
+}
+
+func Test_MapWorkloads_customWorkloadNotInstalled(t *testing.T) {
+	logger.Init()
+	dynamicClient := newDynamicClient(map[schema.GroupVersionResource]string{rollouts: "RolloutList"})
+	dynamicClient.PrependReactor("list", "rollouts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(rollouts.GroupResource(), "")
+	})
+	customWorkloads := []commonModels.CustomWorkload{{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}}
+	mapper := NewMapper(&kubernetes.API{Client: fake.NewSimpleClientset(), Dynamic: dynamicClient}, "testCluster", "testWorkspace", "testRunId", customWorkloads)
+
+	results, err := mapper.MapWorkloads(context.Background(), models.Cluster{Name: "testCluster"})
+
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
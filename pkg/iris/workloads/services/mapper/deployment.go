@@ -1,24 +1,25 @@
 package mapper
 
 import (
-	"reflect"
 	"strconv"
 	"time"
 
 	"github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
-	"github.com/leanix/leanix-k8s-connector/pkg/logger"
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
-func (m *workloadMapper) MapDeploymentsEcst(cluster models.Cluster, deployments *appsv1.DeploymentList, services *v1.ServiceList) ([]models.Data, error) {
+func (m *workloadMapper) MapDeploymentsEcst(cluster models.Cluster, deployments *appsv1.DeploymentList, services *v1.ServiceList, pods *OwnedPods) ([]models.Data, error) {
 	var allDeployments []models.Data
 
 	for _, deployment := range deployments.Items {
-		deploymentService := ""
-		// Check if any service has the exact same selector labels and use this as the service related to the deployment
-		deploymentService = ResolveK8sServiceForK8sDeployment(services, deployment)
-		allDeployments = append(allDeployments, m.CreateDeploymentEcst(cluster, deploymentService, deployment))
+		deploymentServices := kubernetes.SelectingServices(services, deployment.Namespace, deployment.Spec.Template)
+		mappedDeployment := m.CreateDeploymentEcst(cluster, serviceName(deploymentServices), deployment)
+		mappedDeployment.Services = MapServices(deploymentServices)
+		AddRunningImages(mappedDeployment.Workload.WorkloadProperties.Containers, pods.Of(&deployment))
+		allDeployments = append(allDeployments, mappedDeployment)
 	}
 
 	return allDeployments, nil
@@ -37,13 +38,7 @@ func (m *workloadMapper) CreateDeploymentEcst(cluster models.Cluster, deployment
 			WorkloadProperties: models.WorkloadProperties{
 				Replicas:       strconv.FormatInt(int64(deployment.Status.Replicas), 10),
 				UpdateStrategy: string(deployment.Spec.Strategy.Type),
-				Containers: models.Containers{
-					Name:        deployment.Spec.Template.Spec.Containers[0].Name,
-					Image:       deployment.Spec.Template.Spec.Containers[0].Image,
-					Port:        deployment.Spec.Template.Spec.Containers[0].Ports,
-					K8sLimits:   CreateK8sResources(deployment.Spec.Template.Spec.Containers[0].Resources.Limits),
-					K8sRequests: CreateK8sResources(deployment.Spec.Template.Spec.Containers[0].Resources.Requests),
-				},
+				Containers:     MapContainers(deployment.Spec.Template),
 			},
 		},
 		Cluster: models.Cluster{
@@ -59,17 +54,18 @@ func (m *workloadMapper) CreateDeploymentEcst(cluster models.Cluster, deployment
 	return mappedDeployment
 }
 
+// CreateK8sResources maps cpu and memory in canonical form, e.g. 1000m cpu and 1 cpu both map to 1
 func CreateK8sResources(resourceList v1.ResourceList) models.K8sResources {
 	cpu := resourceList[v1.ResourceCPU]
 	cpuString := ""
 	if !cpu.IsZero() {
-		cpuString = cpu.String()
+		cpuString = resource.NewMilliQuantity(cpu.MilliValue(), cpu.Format).String()
 	}
 
 	memory := resourceList[v1.ResourceMemory]
 	memoryString := ""
 	if !memory.IsZero() {
-		memoryString = memory.String()
+		memoryString = resource.NewQuantity(memory.Value(), memory.Format).String()
 	}
 
 	return models.K8sResources{
@@ -77,28 +73,3 @@ func CreateK8sResources(resourceList v1.ResourceList) models.K8sResources {
 		Memory: memoryString,
 	}
 }
-
-func ResolveK8sServiceForK8sDeployment(services *v1.ServiceList, deployment appsv1.Deployment) string {
-	deploymentService := ""
-	if deployment.Spec.Selector != nil {
-
-		for _, service := range services.Items {
-			sharedLabelsDeployment := map[string]string{}
-			sharedLabelsService := map[string]string{}
-			for label := range service.Spec.Selector {
-				if _, ok := deployment.Spec.Selector.MatchLabels[label]; ok {
-					sharedLabelsDeployment[label] = deployment.Spec.Selector.MatchLabels[label]
-					sharedLabelsService[label] = service.Spec.Selector[label]
-				}
-			}
-
-			if len(sharedLabelsDeployment) != 0 && len(sharedLabelsService) != 0 && reflect.DeepEqual(sharedLabelsDeployment, sharedLabelsService) {
-				deploymentService = service.Name
-				break
-			}
-		}
-	} else {
-		logger.Infof("Deployment %s has no selector labels", deployment.Name)
-	}
-	return deploymentService
-}
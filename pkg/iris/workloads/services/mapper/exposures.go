@@ -0,0 +1,224 @@
+package mapper
+
+import (
+	"sort"
+
+	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+	"github.com/leanix/leanix-k8s-connector/pkg/set"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// ingressClassAnnotation names the ingress class of Ingresses created before spec.ingressClassName
+const ingressClassAnnotation = "kubernetes.io/ingress.class"
+
+// Exposures indexes the Ingresses and HTTPRoutes by the services they route to
+type Exposures struct {
+	byService map[string]map[string]*exposure
+}
+
+// exposure collects the hosts, paths and certificates of all rules of an Ingress or HTTPRoute
+// which route to the same service
+type exposure struct {
+	kind             string
+	name             string
+	hosts            *set.String
+	paths            *set.String
+	tlsSecretNames   *set.String
+	ingressClassName string
+	gateways         *set.String
+}
+
+// NewExposures indexes the Ingresses and HTTPRoutes. An HTTPRoute routes to a service in another
+// namespace only if a ReferenceGrant of that namespace allows it, and is attached to Gateways only.
+func NewExposures(ingresses *networkingv1.IngressList, routes *kubernetes.HTTPRouteList, grants *kubernetes.ReferenceGrantList) *Exposures {
+	e := &Exposures{byService: map[string]map[string]*exposure{}}
+	for _, ingress := range ingresses.Items {
+		ingressClassName := ingress.Annotations[ingressClassAnnotation]
+		if ingress.Spec.IngressClassName != nil {
+			ingressClassName = *ingress.Spec.IngressClassName
+		}
+		add := func(backend *networkingv1.IngressServiceBackend, host string, path string) {
+			if backend == nil {
+				return
+			}
+			x := e.exposure(workload.ExposureKindIngress, ingress.Namespace, ingress.Name, ingress.Namespace, backend.Name)
+			x.ingressClassName = ingressClassName
+			if host != "" {
+				x.hosts.Add(host)
+			}
+			x.paths.Add(path)
+			for _, tls := range ingress.Spec.TLS {
+				if tls.SecretName != "" {
+					x.tlsSecretNames.Add(tls.SecretName)
+				}
+			}
+		}
+		if ingress.Spec.DefaultBackend != nil {
+			add(ingress.Spec.DefaultBackend.Service, "", "/")
+		}
+		for _, rule := range ingress.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, path := range rule.HTTP.Paths {
+				add(path.Backend.Service, rule.Host, pathOrRoot(path.Path))
+			}
+		}
+	}
+	for _, route := range routes.Items {
+		for _, rule := range route.Spec.Rules {
+			for _, backend := range rule.BackendRefs {
+				if (backend.Group != "" && backend.Group != "core") || (backend.Kind != "" && backend.Kind != "Service") {
+					continue
+				}
+				serviceNamespace := orDefault(backend.Namespace, route.Namespace)
+				if serviceNamespace != route.Namespace && !referenceGranted(grants, route.Namespace, serviceNamespace, backend.Name) {
+					continue
+				}
+				x := e.exposure(workload.ExposureKindHTTPRoute, route.Namespace, route.Name, serviceNamespace, backend.Name)
+				for _, host := range route.Spec.Hostnames {
+					x.hosts.Add(host)
+				}
+				// a rule without matches matches all requests
+				if len(rule.Matches) == 0 {
+					x.paths.Add("/")
+				}
+				for _, match := range rule.Matches {
+					if match.Path == nil {
+						x.paths.Add("/")
+					} else {
+						x.paths.Add(pathOrRoot(match.Path.Value))
+					}
+				}
+				for _, parent := range route.Spec.ParentRefs {
+					if orDefault(parent.Group, kubernetes.GatewayGroup) != kubernetes.GatewayGroup || orDefault(parent.Kind, "Gateway") != "Gateway" {
+						continue
+					}
+					x.gateways.Add(orDefault(parent.Namespace, route.Namespace) + "/" + parent.Name)
+				}
+			}
+		}
+	}
+	return e
+}
+
+// Of returns the exposures of the services ordered by kind and name, nil if none is exposed. An
+// Ingress or HTTPRoute routing to several of the services is returned once.
+func (e *Exposures) Of(namespace string, services []string) []workload.Exposure {
+	if e == nil {
+		return nil
+	}
+	merged := map[string]*exposure{}
+	for _, service := range services {
+		for key, x := range e.byService[namespace+"/"+service] {
+			if merged[key] == nil {
+				merged[key] = newExposure(x.kind, x.name)
+			}
+			merged[key].merge(x)
+		}
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(merged))
+	for key := range merged {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	exposures := make([]workload.Exposure, 0, len(keys))
+	for _, key := range keys {
+		x := merged[key]
+		exposures = append(exposures, workload.Exposure{
+			Kind:             x.kind,
+			Name:             x.name,
+			Hosts:            x.hosts.Items(),
+			Paths:            x.paths.Items(),
+			TLSSecretNames:   x.tlsSecretNames.Items(),
+			IngressClassName: x.ingressClassName,
+			Gateways:         x.gateways.Items(),
+		})
+	}
+	return exposures
+}
+
+func (e *Exposures) exposure(kind string, namespace string, name string, serviceNamespace string, service string) *exposure {
+	serviceKey := serviceNamespace + "/" + service
+	if e.byService[serviceKey] == nil {
+		e.byService[serviceKey] = map[string]*exposure{}
+	}
+	key := kind + "/" + namespace + "/" + name
+	x, ok := e.byService[serviceKey][key]
+	if !ok {
+		x = newExposure(kind, name)
+		e.byService[serviceKey][key] = x
+	}
+	return x
+}
+
+func newExposure(kind string, name string) *exposure {
+	return &exposure{
+		kind:           kind,
+		name:           name,
+		hosts:          set.NewStringSet(),
+		paths:          set.NewStringSet(),
+		tlsSecretNames: set.NewStringSet(),
+		gateways:       set.NewStringSet(),
+	}
+}
+
+func (x *exposure) merge(other *exposure) {
+	for _, s := range []struct{ to, from *set.String }{
+		{x.hosts, other.hosts},
+		{x.paths, other.paths},
+		{x.tlsSecretNames, other.tlsSecretNames},
+		{x.gateways, other.gateways},
+	} {
+		for _, item := range s.from.Items() {
+			s.to.Add(item)
+		}
+	}
+	x.ingressClassName = other.ingressClassName
+}
+
+// referenceGranted tells whether a ReferenceGrant in the namespace of the service allows the
+// HTTPRoutes of routeNamespace to route to it
+func referenceGranted(grants *kubernetes.ReferenceGrantList, routeNamespace string, serviceNamespace string, service string) bool {
+	if grants == nil {
+		return false
+	}
+	for _, grant := range grants.Items {
+		if grant.Namespace != serviceNamespace {
+			continue
+		}
+		from := false
+		for _, f := range grant.Spec.From {
+			if f.Group == kubernetes.GatewayGroup && f.Kind == "HTTPRoute" && f.Namespace == routeNamespace {
+				from = true
+			}
+		}
+		if !from {
+			continue
+		}
+		for _, to := range grant.Spec.To {
+			if (to.Group == "" || to.Group == "core") && to.Kind == "Service" && (to.Name == "" || to.Name == service) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+func orDefault(value string, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,169 @@
+package mapper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+	"github.com/leanix/leanix-k8s-connector/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+)
+
+func ingressPath(path string, service string) networkingv1.HTTPIngressPath {
+	return networkingv1.HTTPIngressPath{
+		Path:    path,
+		Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: service}},
+	}
+}
+
+func Test_MapWorkloads_exposures(t *testing.T) {
+	logger.Init()
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "shop"}}
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "web"},
+			Spec: appsv1.DeploymentSpec{
+				Selector: selector,
+				Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: selector.MatchLabels}},
+			},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "web"},
+			Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "worker"}}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "shop-svc", Namespace: "web"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "shop"}},
+		},
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "web"},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: pointer.String("nginx"),
+				TLS:              []networkingv1.IngressTLS{{Hosts: []string{"shop.example.com"}, SecretName: "shop-tls"}},
+				Rules: []networkingv1.IngressRule{
+					{Host: "shop.example.com", IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{ingressPath("/cart", "shop-svc"), ingressPath("", "shop-svc"), ingressPath("/admin", "admin-svc")},
+					}}},
+					{Host: "www.example.com", IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{ingressPath("/", "shop-svc")},
+					}}},
+				},
+			},
+		},
+		// a service of the same name in another namespace is a different service
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other", Annotations: map[string]string{ingressClassAnnotation: "traefik"}},
+			Spec:       networkingv1.IngressSpec{DefaultBackend: &networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: "shop-svc"}}},
+		},
+	)
+	route := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "HTTPRoute",
+		"metadata":   map[string]interface{}{"name": "shop-api", "namespace": "web"},
+		"spec": map[string]interface{}{
+			"parentRefs": []interface{}{map[string]interface{}{"name": "public", "namespace": "gateways"}},
+			"hostnames":  []interface{}{"api.example.com"},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"matches":     []interface{}{map[string]interface{}{"path": map[string]interface{}{"type": "PathPrefix", "value": "/v1"}}},
+					"backendRefs": []interface{}{map[string]interface{}{"name": "shop-svc", "port": int64(8080)}},
+				},
+				map[string]interface{}{
+					"backendRefs": []interface{}{map[string]interface{}{"kind": "Bucket", "group": "storage.example.com", "name": "shop-svc"}},
+				},
+			},
+		},
+	}}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{kubernetes.HTTPRouteResource: "HTTPRouteList", kubernetes.ReferenceGrantResource: "ReferenceGrantList"}, route)
+	mapper := NewMapper(&kubernetes.API{Client: client, Dynamic: dynamicClient}, "testCluster", "testWorkspace", "testRunId", nil)
+
+	workloads, err := mapper.MapWorkloads(context.Background(), models.Cluster{Name: "testCluster"})
+
+	assert.NoError(t, err)
+	assert.Len(t, workloads, 2)
+	exposed := workloads[0]
+	assert.Equal(t, "shop", exposed.Workload.Name)
+	assert.Equal(t, []models.Exposure{
+		{
+			Kind:           models.ExposureKindHTTPRoute,
+			Name:           "shop-api",
+			Hosts:          []string{"api.example.com"},
+			Paths:          []string{"/v1"},
+			TLSSecretNames: []string{},
+			Gateways:       []string{"gateways/public"},
+		},
+		{
+			Kind:             models.ExposureKindIngress,
+			Name:             "shop",
+			Hosts:            []string{"shop.example.com", "www.example.com"},
+			Paths:            []string{"/", "/cart"},
+			TLSSecretNames:   []string{"shop-tls"},
+			IngressClassName: "nginx",
+			Gateways:         []string{},
+		},
+	}, exposed.Exposures)
+	assert.Equal(t, "worker", workloads[1].Workload.Name)
+	assert.Nil(t, workloads[1].Exposures)
+}
+
+func TestExposures_Of(t *testing.T) {
+	ingresses := &networkingv1.IngressList{Items: []networkingv1.Ingress{{
+		ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "web", Annotations: map[string]string{ingressClassAnnotation: "traefik"}},
+		Spec:       networkingv1.IngressSpec{DefaultBackend: &networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: "shop"}}},
+	}}}
+	routes := &kubernetes.HTTPRouteList{Items: []kubernetes.HTTPRoute{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "gateways"},
+			Spec: kubernetes.HTTPRouteSpec{
+				// a route of a service mesh is attached to a service instead of a gateway
+				ParentRefs: []kubernetes.ParentReference{{Name: "public"}, {Kind: "Service", Name: "shop", Namespace: "web"}},
+				Rules:      []kubernetes.HTTPRouteRule{{BackendRefs: []kubernetes.BackendRef{{Name: "shop", Namespace: "web"}}}},
+			},
+		},
+		// no grant allows the routes of this namespace to route to the service
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ungranted", Namespace: "other"},
+			Spec: kubernetes.HTTPRouteSpec{
+				ParentRefs: []kubernetes.ParentReference{{Name: "public", Namespace: "gateways"}},
+				Rules:      []kubernetes.HTTPRouteRule{{BackendRefs: []kubernetes.BackendRef{{Name: "shop", Namespace: "web"}}}},
+			},
+		},
+	}}
+	grants := &kubernetes.ReferenceGrantList{Items: []kubernetes.ReferenceGrant{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "routes-from-gateways", Namespace: "web"},
+			Spec: kubernetes.ReferenceGrantSpec{
+				From: []kubernetes.ReferenceGrantFrom{{Group: kubernetes.GatewayGroup, Kind: "HTTPRoute", Namespace: "gateways"}},
+				To:   []kubernetes.ReferenceGrantTo{{Kind: "Service"}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "routes-from-other", Namespace: "web"},
+			Spec: kubernetes.ReferenceGrantSpec{
+				From: []kubernetes.ReferenceGrantFrom{{Group: kubernetes.GatewayGroup, Kind: "HTTPRoute", Namespace: "other"}},
+				To:   []kubernetes.ReferenceGrantTo{{Kind: "Service", Name: "admin"}},
+			},
+		},
+	}}
+
+	exposures := NewExposures(ingresses, routes, grants)
+
+	assert.Equal(t, []models.Exposure{
+		{Kind: models.ExposureKindHTTPRoute, Name: "shared", Hosts: []string{}, Paths: []string{"/"}, TLSSecretNames: []string{}, Gateways: []string{"gateways/public"}},
+		{Kind: models.ExposureKindIngress, Name: "legacy", Hosts: []string{}, Paths: []string{"/"}, TLSSecretNames: []string{}, IngressClassName: "traefik", Gateways: []string{}},
+	}, exposures.Of("web", []string{"shop"}))
+	assert.Nil(t, exposures.Of("gateways", []string{"shop"}))
+	assert.Nil(t, exposures.Of("web", nil))
+}
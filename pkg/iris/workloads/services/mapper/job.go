@@ -0,0 +1,57 @@
+package mapper
+
+import (
+	"strconv"
+	"time"
+
+	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// MapJobsEcst maps the Jobs which are not created by a CronJob or another controller
+func (m *workloadMapper) MapJobsEcst(cluster workload.Cluster, jobs *batchv1.JobList, services *v1.ServiceList, pods *OwnedPods) ([]workload.Data, error) {
+	var allJobs []workload.Data
+
+	for _, job := range jobs.Items {
+		if hasController(&job) {
+			continue
+		}
+		jobServices := kubernetes.SelectingServices(services, job.Namespace, job.Spec.Template)
+		mappedJob := m.CreateJobEcst(cluster, job, serviceName(jobServices))
+		mappedJob.Services = MapServices(jobServices)
+		AddRunningImages(mappedJob.Workload.WorkloadProperties.Containers, pods.Of(&job))
+		allJobs = append(allJobs, mappedJob)
+	}
+
+	return allJobs, nil
+}
+
+// CreateJobEcst create a data object that contains name, labels, Job properties and more
+func (m *workloadMapper) CreateJobEcst(cluster workload.Cluster, job batchv1.Job, service string) workload.Data {
+	replicas := ""
+	if job.Spec.Parallelism != nil {
+		replicas = strconv.FormatInt(int64(*job.Spec.Parallelism), 10)
+	}
+	return workload.Data{
+		Workload: workload.Workload{
+			Name:         job.Name,
+			WorkloadType: "job",
+			Labels:       job.ObjectMeta.Labels,
+			WorkloadProperties: workload.WorkloadProperties{
+				Replicas:   replicas,
+				Containers: MapContainers(job.Spec.Template),
+			},
+		},
+		Cluster: workload.Cluster{
+			Name:       cluster.Name,
+			OsImage:    cluster.OsImage,
+			NoOfNodes:  cluster.NoOfNodes,
+			K8sVersion: cluster.K8sVersion,
+		},
+		ServiceName:   service,
+		NamespaceName: job.Namespace,
+		Timestamp:     job.CreationTimestamp.UTC().Format(time.RFC3339),
+	}
+}
@@ -1,78 +1,124 @@
 package mapper
 
 import (
+	"context"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
 	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
 	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
 	"github.com/leanix/leanix-k8s-connector/pkg/set"
+	"github.com/leanix/leanix-k8s-connector/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	v1 "k8s.io/api/core/v1"
 	"strings"
 )
 
 type WorkloadMapper interface {
 	MapCluster(clusterName string, nodes *v1.NodeList) (workload.Cluster, error)
-	MapWorkloads(cluster workload.Cluster) ([]workload.Data, error)
+	MapWorkloads(ctx context.Context, cluster workload.Cluster) ([]workload.Data, error)
 }
 
 type workloadMapper struct {
-	KubernetesApi *kubernetes.API
-	ClusterName   string
-	WorkspaceId   string
-	runId         string
+	KubernetesApi   *kubernetes.API
+	ClusterName     string
+	WorkspaceId     string
+	runId           string
+	customWorkloads []models.CustomWorkload
 }
 
 func NewMapper(
 	kubernetesApi *kubernetes.API,
 	clusterName string,
 	workspaceId string,
-	runId string) WorkloadMapper {
+	runId string,
+	customWorkloads []models.CustomWorkload) WorkloadMapper {
 	return &workloadMapper{
-		KubernetesApi: kubernetesApi,
-		ClusterName:   clusterName,
-		WorkspaceId:   workspaceId,
-		runId:         runId,
+		KubernetesApi:   kubernetesApi,
+		ClusterName:     clusterName,
+		WorkspaceId:     workspaceId,
+		runId:           runId,
+		customWorkloads: customWorkloads,
 	}
 }
 
-func (m *workloadMapper) MapWorkloads(cluster workload.Cluster) ([]workload.Data, error) {
+func (m *workloadMapper) MapWorkloads(ctx context.Context, cluster workload.Cluster) (scannedWorkloads []workload.Data, err error) {
+	ctx, span := tracing.Start(ctx, "MapWorkloads", attribute.String("k8s.cluster.name", cluster.Name))
+	defer func() {
+		span.SetAttributes(attribute.Int("workloads", len(scannedWorkloads)))
+		tracing.End(span, err)
+	}()
+
+	services, err := m.KubernetesApi.Services(ctx, "")
+	if err != nil {
+		return nil, err
+	}
 
-	var scannedWorkloads []workload.Data
-	services, err := m.KubernetesApi.Services("")
+	pods, err := m.KubernetesApi.Pods(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	replicaSets, err := m.KubernetesApi.ReplicaSets(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := m.KubernetesApi.Jobs(ctx, "")
 	if err != nil {
 		return nil, err
 	}
+	// ReplicaSets and Jobs sit between the workloads and their pods
+	ownedPods := NewOwnedPods(pods, replicaSets, jobs)
 
-	deployments, err := m.KubernetesApi.Deployments("")
+	deployments, err := m.KubernetesApi.Deployments(ctx, "")
 	if err != nil {
 		return nil, err
 	}
-	mappedDeployments, err := m.MapDeploymentsEcst(cluster, deployments, services)
+	mappedDeployments, err := m.MapDeploymentsEcst(cluster, deployments, services, ownedPods)
 	if err != nil {
 		return nil, err
 	}
 
-	cronJobs, err := m.KubernetesApi.CronJobs("")
+	cronJobs, err := m.KubernetesApi.CronJobs(ctx, "")
 	if err != nil {
 		return nil, err
 	}
-	mappedCronJobs, err := m.MapCronJobsEcst(cluster, cronJobs, services)
+	mappedCronJobs, err := m.MapCronJobsEcst(cluster, cronJobs, services, ownedPods)
 	if err != nil {
 		return nil, err
 	}
 
-	statefulSets, err := m.KubernetesApi.StatefulSets("")
+	statefulSets, err := m.KubernetesApi.StatefulSets(ctx, "")
 	if err != nil {
 		return nil, err
 	}
-	MappedStatefulSets, err := m.MapStatefulSetsEcst(cluster, statefulSets, services)
+	MappedStatefulSets, err := m.MapStatefulSetsEcst(cluster, statefulSets, services, ownedPods)
 	if err != nil {
 		return nil, err
 	}
 
-	daemonSets, err := m.KubernetesApi.DaemonSets("")
+	daemonSets, err := m.KubernetesApi.DaemonSets(ctx, "")
 	if err != nil {
 		return nil, err
 	}
-	MappedDaemonSets, err := m.MapDaemonSetsEcst(cluster, daemonSets, services)
+	MappedDaemonSets, err := m.MapDaemonSetsEcst(cluster, daemonSets, services, ownedPods)
+	if err != nil {
+		return nil, err
+	}
+
+	mappedCustomWorkloads, err := m.MapCustomWorkloadsEcst(ctx, cluster, services, ownedPods)
+	if err != nil {
+		return nil, err
+	}
+
+	// bare Jobs, ReplicaSets and pods are only mapped if no workload above controls them
+	mappedJobs, err := m.MapJobsEcst(cluster, jobs, services, ownedPods)
+	if err != nil {
+		return nil, err
+	}
+	mappedReplicaSets, err := m.MapReplicaSetsEcst(cluster, replicaSets, services, ownedPods)
+	if err != nil {
+		return nil, err
+	}
+	mappedPods, err := m.MapPodsEcst(cluster, pods, services)
 	if err != nil {
 		return nil, err
 	}
@@ -81,9 +127,42 @@ func (m *workloadMapper) MapWorkloads(cluster workload.Cluster) ([]workload.Data
 	scannedWorkloads = append(scannedWorkloads, mappedCronJobs...)
 	scannedWorkloads = append(scannedWorkloads, MappedStatefulSets...)
 	scannedWorkloads = append(scannedWorkloads, MappedDaemonSets...)
+	scannedWorkloads = append(scannedWorkloads, mappedCustomWorkloads...)
+	scannedWorkloads = append(scannedWorkloads, mappedJobs...)
+	scannedWorkloads = append(scannedWorkloads, mappedReplicaSets...)
+	scannedWorkloads = append(scannedWorkloads, mappedPods...)
+
+	exposures, err := m.exposures(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range scannedWorkloads {
+		serviceNames := make([]string, 0, len(scannedWorkloads[i].Services))
+		for _, service := range scannedWorkloads[i].Services {
+			serviceNames = append(serviceNames, service.Name)
+		}
+		scannedWorkloads[i].Exposures = exposures.Of(scannedWorkloads[i].NamespaceName, serviceNames)
+	}
 	return scannedWorkloads, nil
 }
 
+// exposures lists the Ingresses and HTTPRoutes of the cluster by the services they route to
+func (m *workloadMapper) exposures(ctx context.Context) (*Exposures, error) {
+	ingresses, err := m.KubernetesApi.Ingresses(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	routes, err := m.KubernetesApi.HTTPRoutes(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	grants, err := m.KubernetesApi.ReferenceGrants(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return NewExposures(ingresses, routes, grants), nil
+}
+
 func (m *workloadMapper) MapCluster(clusterName string, nodes *v1.NodeList) (workload.Cluster, error) {
 	items := nodes.Items
 	if len(items) == 0 {
@@ -1,11 +1,14 @@
 package mapper
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"github.com/leanix/leanix-k8s-connector/pkg/diff"
 	"github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
 	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+	"github.com/leanix/leanix-k8s-connector/pkg/logger"
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
@@ -24,7 +27,8 @@ func Test_MapWorkloads_success(t *testing.T) {
 				Selector: map[string]string{"app": "service-1"},
 			},
 			ObjectMeta: metav1.ObjectMeta{
-				Name: "service-1",
+				Name:      "service-1",
+				Namespace: "deployment-1-namespace",
 				Labels: map[string]string{
 					"name": "service-1",
 					"failure-domain.beta.kubernetes.io/region": "westeurope",
@@ -38,7 +42,8 @@ func Test_MapWorkloads_success(t *testing.T) {
 				Selector: map[string]string{"app": "service-2"},
 			},
 			ObjectMeta: metav1.ObjectMeta{
-				Name: "service-2",
+				Name:      "service-2",
+				Namespace: "deployment-1-namespace",
 				Labels: map[string]string{
 					"name": "nodepool-2",
 					"failure-domain.beta.kubernetes.io/region": "westeurope",
@@ -49,10 +54,19 @@ func Test_MapWorkloads_success(t *testing.T) {
 		},
 	}
 
+	// services only select the pods in their own namespace
+	for namespace, service := range map[string]string{"cronjob-1-namespace": "service-2", "statefulset-1-namespace": "service-1", "daemonset-1-namespace": "service-1"} {
+		dummyServices = append(dummyServices, &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: service, Namespace: namespace},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": service}},
+		})
+	}
+
 	dummyDeployments := []runtime.Object{
 		&appsv1.Deployment{
 			Spec: appsv1.DeploymentSpec{
 				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "service-2"}},
 					Spec: corev1.PodSpec{
 						Containers: []corev1.Container{
 							{
@@ -113,6 +127,7 @@ func Test_MapWorkloads_success(t *testing.T) {
 					MatchLabels: map[string]string{"app": "service-1"},
 				},
 				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "service-1"}},
 					Spec: corev1.PodSpec{
 						Containers: []corev1.Container{
 							{
@@ -159,6 +174,7 @@ func Test_MapWorkloads_success(t *testing.T) {
 							MatchLabels: map[string]string{"app": "service-2"},
 						},
 						Template: corev1.PodTemplateSpec{
+							ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "service-2"}},
 							Spec: corev1.PodSpec{
 								Containers: []corev1.Container{
 									{
@@ -202,6 +218,7 @@ func Test_MapWorkloads_success(t *testing.T) {
 					MatchLabels: map[string]string{"app": "service-1"},
 				},
 				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "service-1"}},
 					Spec: corev1.PodSpec{
 						Containers: []corev1.Container{
 							{
@@ -245,6 +262,7 @@ func Test_MapWorkloads_success(t *testing.T) {
 					MatchLabels: map[string]string{"app": "service-1"},
 				},
 				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "service-1"}},
 					Spec: corev1.PodSpec{
 						Containers: []corev1.Container{
 							{
@@ -272,8 +290,8 @@ func Test_MapWorkloads_success(t *testing.T) {
 		Name:    "testCluster",
 		OsImage: "linux",
 	}
-	mapper := NewMapper(&mockApi, "testCluster", "testWorkspace", "testRunId")
-	results, err := mapper.MapWorkloads(testCluster)
+	mapper := NewMapper(&mockApi, "testCluster", "testWorkspace", "testRunId", nil)
+	results, err := mapper.MapWorkloads(context.Background(), testCluster)
 
 	assert.NoError(t, err)
 	assert.NotEmpty(t, results)
@@ -298,6 +316,156 @@ func Test_MapWorkloads_success(t *testing.T) {
 	assert.Equal(t, "test-daemonset-1", results[4].Workload.Name)
 	assert.Equal(t, "service-1", results[4].ServiceName)
 	assert.Equal(t, "daemonSet", results[4].Workload.WorkloadType)
-	assert.Equal(t, "50", results[4].Workload.WorkloadProperties.Containers.K8sLimits.Memory)
+	assert.Equal(t, "50", results[4].Workload.WorkloadProperties.Containers[0].K8sLimits.Memory)
+
+}
+
+func Test_CreateDeploymentEcst_containers(t *testing.T) {
+	always := corev1.ContainerRestartPolicyAlways
+	deployment := appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-deployment-1",
+			Namespace: "deployment-1-namespace",
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{
+						{Name: "migrate", Image: "migrate:1.0"},
+						{Name: "proxy", Image: "envoy:1.29", RestartPolicy: &always},
+					},
+					Containers: []corev1.Container{
+						{Name: "app", Image: "app:2.0"},
+					},
+				},
+			},
+		},
+	}
+	mapper := &workloadMapper{}
+
+	result := mapper.CreateDeploymentEcst(models.Cluster{Name: "testCluster"}, "", deployment)
+
+	containers := result.Workload.WorkloadProperties.Containers
+	assert.Len(t, containers, 3)
+	assert.Equal(t, "migrate", containers[0].Name)
+	assert.Equal(t, "init", containers[0].Role)
+	assert.Equal(t, "proxy", containers[1].Name)
+	assert.Equal(t, "sidecar", containers[1].Role)
+	assert.Equal(t, "app", containers[2].Name)
+	assert.Equal(t, "main", containers[2].Role)
+	assert.Equal(t, "app:2.0", containers[2].Image)
+	assert.Equal(t, "docker.io", containers[2].ImageRegistry)
+	assert.Equal(t, "library/app", containers[2].ImageRepository)
+	assert.Equal(t, "2.0", containers[2].ImageTag)
+	assert.Empty(t, containers[2].ImageDigest)
+}
+
+func Test_CreateDeploymentEcst_noContainers(t *testing.T) {
+	deployment := appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-deployment-1",
+			Namespace: "deployment-1-namespace",
+		},
+	}
+	mapper := &workloadMapper{}
+
+	result := mapper.CreateDeploymentEcst(models.Cluster{Name: "testCluster"}, "", deployment)
+
+	assert.Empty(t, result.Workload.WorkloadProperties.Containers)
+}
+
+// deterministicObjects returns a cluster whose nodes and pod template list their values in the given order
+func deterministicObjects(reversed bool) []runtime.Object {
+	nodeInfos := []corev1.NodeSystemInfo{
+		{OSImage: "Ubuntu 22.04", KubeletVersion: "v1.30.2", Architecture: "amd64"},
+		{OSImage: "Bottlerocket", KubeletVersion: "v1.29.6", Architecture: "arm64"},
+		{OSImage: "Flatcar", KubeletVersion: "v1.30.1", Architecture: "amd64"},
+	}
+	containers := []corev1.Container{
+		{
+			Name:  "app",
+			Image: "app:2.0",
+			Ports: []corev1.ContainerPort{
+				{Name: "http", ContainerPort: 8080, Protocol: corev1.ProtocolTCP},
+				{Name: "metrics", ContainerPort: 9090, Protocol: corev1.ProtocolTCP},
+				{Name: "dns", ContainerPort: 53, Protocol: corev1.ProtocolUDP},
+			},
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("500m"),
+					corev1.ResourceMemory: resource.MustParse("256Mi"),
+				},
+			},
+		},
+		{Name: "worker", Image: "worker:2.0"},
+	}
+	if reversed {
+		for i, j := 0, len(nodeInfos)-1; i < j; i, j = i+1, j-1 {
+			nodeInfos[i], nodeInfos[j] = nodeInfos[j], nodeInfos[i]
+		}
+		ports := containers[0].Ports
+		containers[0].Ports = []corev1.ContainerPort{ports[2], ports[1], ports[0]}
+		containers[0], containers[1] = containers[1], containers[0]
+	}
+
+	objects := make([]runtime.Object, 0)
+	for i, info := range nodeInfos {
+		objects = append(objects, &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: []string{"node-a", "node-b", "node-c"}[i]},
+			Status:     corev1.NodeStatus{NodeInfo: info},
+		})
+	}
+	return append(objects, &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "shop",
+			Namespace: "web",
+			Labels:    map[string]string{"app": "shop", "team": "checkout", "tier": "web"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: pointer.Int32(2),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{kubernetes.DefaultContainerAnnotation: "app"},
+				},
+				Spec: corev1.PodSpec{Containers: containers},
+			},
+		},
+	})
+}
+
+func mapDeterministicObjects(t *testing.T, reversed bool) []models.Data {
+	mockApi := &kubernetes.API{Client: fake.NewSimpleClientset(deterministicObjects(reversed)...)}
+	mapper := NewMapper(mockApi, "testCluster", "testWorkspace", "testRunId", nil)
+	nodes, err := mockApi.Nodes(context.Background())
+	assert.NoError(t, err)
+	cluster, err := mapper.MapCluster("testCluster", nodes)
+	assert.NoError(t, err)
+	results, err := mapper.MapWorkloads(context.Background(), cluster)
+	assert.NoError(t, err)
+	return results
+}
+
+func Test_MapWorkloads_deterministic(t *testing.T) {
+	logger.Init()
+	first := mapDeterministicObjects(t, false)
+	assert.Equal(t, "Bottlerocket, Flatcar, Ubuntu 22.04", first[0].Cluster.OsImage)
+
+	for i := 0; i < 20; i++ {
+		changes, err := diff.Compare(first, mapDeterministicObjects(t, false))
+		assert.NoError(t, err)
+		assert.Empty(t, changes, "run %d", i)
+	}
+	// reordering the nodes, containers or ports does not change the payload either
+	changes, err := diff.Compare(first, mapDeterministicObjects(t, true))
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func Test_CreateK8sResources_canonical(t *testing.T) {
+	resources := CreateK8sResources(corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("1000m"),
+		corev1.ResourceMemory: resource.MustParse("1024Mi"),
+	})
 
+	assert.Equal(t, models.K8sResources{Cpu: "1", Memory: "1Gi"}, resources)
 }
@@ -0,0 +1,58 @@
+package mapper
+
+import (
+	"time"
+
+	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+	v1 "k8s.io/api/core/v1"
+)
+
+// MapPodsEcst maps the pods which are not managed by any controller, e.g. pods created with
+// kubectl run
+func (m *workloadMapper) MapPodsEcst(cluster workload.Cluster, pods *v1.PodList, services *v1.ServiceList) ([]workload.Data, error) {
+	var allPods []workload.Data
+
+	for _, pod := range pods.Items {
+		if hasController(&pod) {
+			continue
+		}
+		template := podTemplate(pod)
+		podServices := kubernetes.SelectingServices(services, pod.Namespace, template)
+		mappedPod := m.CreatePodEcst(cluster, pod, serviceName(podServices))
+		mappedPod.Services = MapServices(podServices)
+		AddRunningImages(mappedPod.Workload.WorkloadProperties.Containers, []v1.Pod{pod})
+		allPods = append(allPods, mappedPod)
+	}
+
+	return allPods, nil
+}
+
+// CreatePodEcst create a data object that contains name, labels, containers and more of a pod
+func (m *workloadMapper) CreatePodEcst(cluster workload.Cluster, pod v1.Pod, service string) workload.Data {
+	return workload.Data{
+		Workload: workload.Workload{
+			Name:         pod.Name,
+			WorkloadType: "pod",
+			Labels:       pod.ObjectMeta.Labels,
+			WorkloadProperties: workload.WorkloadProperties{
+				Replicas:   "1",
+				Containers: MapContainers(podTemplate(pod)),
+			},
+		},
+		Cluster: workload.Cluster{
+			Name:       cluster.Name,
+			OsImage:    cluster.OsImage,
+			NoOfNodes:  cluster.NoOfNodes,
+			K8sVersion: cluster.K8sVersion,
+		},
+		ServiceName:   service,
+		NamespaceName: pod.Namespace,
+		Timestamp:     pod.CreationTimestamp.UTC().Format(time.RFC3339),
+	}
+}
+
+// podTemplate is the template a pod would have been created from by a controller
+func podTemplate(pod v1.Pod) v1.PodTemplateSpec {
+	return v1.PodTemplateSpec{ObjectMeta: pod.ObjectMeta, Spec: pod.Spec}
+}
@@ -0,0 +1,97 @@
+package mapper
+
+import (
+	"github.com/leanix/leanix-k8s-connector/pkg/image"
+	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+	"github.com/leanix/leanix-k8s-connector/pkg/set"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// OwnedPods indexes pods by the workload controlling them. Deployments control their pods through
+// ReplicaSets and CronJobs through Jobs. StatefulSets and DaemonSets control their pods directly,
+// their ControllerRevisions only record the template history.
+type OwnedPods struct {
+	byOwner map[types.UID][]v1.Pod
+}
+
+func NewOwnedPods(pods *v1.PodList, replicaSets *appsv1.ReplicaSetList, jobs *batchv1.JobList) *OwnedPods {
+	// ReplicaSets and Jobs resolve to the workload controlling them
+	workloadOf := map[types.UID]types.UID{}
+	for i := range replicaSets.Items {
+		if owner := metav1.GetControllerOf(&replicaSets.Items[i]); owner != nil {
+			workloadOf[replicaSets.Items[i].UID] = owner.UID
+		}
+	}
+	for i := range jobs.Items {
+		if owner := metav1.GetControllerOf(&jobs.Items[i]); owner != nil {
+			workloadOf[jobs.Items[i].UID] = owner.UID
+		}
+	}
+
+	byOwner := map[types.UID][]v1.Pod{}
+	for i := range pods.Items {
+		owner := metav1.GetControllerOf(&pods.Items[i])
+		if owner == nil {
+			continue
+		}
+		uid := owner.UID
+		if workloadUID, ok := workloadOf[uid]; ok {
+			uid = workloadUID
+		}
+		byOwner[uid] = append(byOwner[uid], pods.Items[i])
+	}
+	return &OwnedPods{byOwner: byOwner}
+}
+
+// Of returns the pods controlled by the workload
+func (o *OwnedPods) Of(owner metav1.Object) []v1.Pod {
+	if o == nil {
+		return nil
+	}
+	return o.byOwner[owner.GetUID()]
+}
+
+// hasController tells whether the object is managed by a controller, e.g. a workload, an operator
+// or a node for static pods. Only objects without one are mapped as workloads of their own.
+func hasController(object metav1.Object) bool {
+	return metav1.GetControllerOf(object) != nil
+}
+
+// AddRunningImages sets the distinct image IDs the running pods report for each container. Pending, succeeded and failed pods run nothing and are left out, like
+// containers that are not started yet and report no image ID.
+func AddRunningImages(containers workload.Containers, pods []v1.Pod) {
+	for i := range containers {
+		imageIDs := set.NewStringSet()
+		for _, pod := range pods {
+			if pod.Status.Phase != v1.PodRunning {
+				continue
+			}
+			for _, status := range containerStatuses(pod) {
+				if status.Name == containers[i].Name && status.ImageID != "" {
+					imageIDs.Add(status.ImageID)
+				}
+			}
+		}
+		// the image IDs are sorted, so the payload only changes with them
+		items := imageIDs.Items()
+		runningImages := make([]workload.RunningImage, 0, len(items))
+		for _, imageID := range items {
+			runningImages = append(runningImages, workload.RunningImage{
+				ImageID: imageID,
+				Digest:  image.DigestFromImageID(imageID),
+			})
+		}
+		containers[i].RunningImages = runningImages
+	}
+}
+
+func containerStatuses(pod v1.Pod) []v1.ContainerStatus {
+	statuses := make([]v1.ContainerStatus, 0, len(pod.Status.InitContainerStatuses)+len(pod.Status.ContainerStatuses)+len(pod.Status.EphemeralContainerStatuses))
+	statuses = append(statuses, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	return append(statuses, pod.Status.EphemeralContainerStatuses...)
+}
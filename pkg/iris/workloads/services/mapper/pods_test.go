@@ -0,0 +1,224 @@
+package mapper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+	"github.com/leanix/leanix-k8s-connector/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	digestV1 = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	digestV2 = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+func controlledBy(kind string, name string, uid types.UID) []metav1.OwnerReference {
+	apiVersion := "apps/v1"
+	if kind == "Job" || kind == "CronJob" {
+		apiVersion = "batch/v1"
+	}
+	return []metav1.OwnerReference{{APIVersion: apiVersion, Kind: kind, Name: name, UID: uid, Controller: pointer.Bool(true)}}
+}
+
+func runningPod(name string, owners []metav1.OwnerReference, imageIDs map[string]string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", OwnerReferences: owners},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	for container, imageID := range imageIDs {
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, corev1.ContainerStatus{Name: container, ImageID: imageID})
+	}
+	return pod
+}
+
+func Test_MapWorkloads_runningImages(t *testing.T) {
+	logger.Init()
+	template := corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "app", Image: "app:latest"},
+				{Name: "proxy", Image: "envoy:1.29"},
+			},
+		},
+	}
+	deploymentOwners := controlledBy("ReplicaSet", "app-5d8f", "rs-uid")
+	cronJobOwners := controlledBy("Job", "backup-28000000", "job-uid")
+	mockApi := kubernetes.API{
+		Client: fake.NewSimpleClientset(
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test", UID: "deployment-uid"},
+				Spec:       appsv1.DeploymentSpec{Template: template},
+			},
+			&appsv1.ReplicaSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "app-5d8f", Namespace: "test", UID: "rs-uid", OwnerReferences: controlledBy("Deployment", "app", "deployment-uid")},
+			},
+			runningPod("app-5d8f-a", deploymentOwners, map[string]string{"app": "docker-pullable://app@" + digestV2, "proxy": "docker-pullable://envoy@" + digestV1}),
+			runningPod("app-5d8f-b", deploymentOwners, map[string]string{"app": "docker-pullable://app@" + digestV1, "proxy": "docker-pullable://envoy@" + digestV1}),
+			runningPod("app-5d8f-c", deploymentOwners, map[string]string{"app": "docker-pullable://app@" + digestV2, "proxy": ""}),
+			&batchv1.CronJob{
+				ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "test", UID: "cronjob-uid"},
+				Spec:       batchv1.CronJobSpec{JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: template}}},
+			},
+			&batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: "backup-28000000", Namespace: "test", UID: "job-uid", OwnerReferences: controlledBy("CronJob", "backup", "cronjob-uid")},
+			},
+			runningPod("backup-28000000-x", cronJobOwners, map[string]string{"app": digestV1}),
+			&appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "test", UID: "statefulset-uid"},
+				Spec:       appsv1.StatefulSetSpec{Template: template},
+			},
+			runningPod("db-0", controlledBy("StatefulSet", "db", "statefulset-uid"), map[string]string{"app": "docker://" + digestV2}),
+			// pods without a controller belong to no workload
+			runningPod("debug", nil, map[string]string{"app": digestV1}),
+		),
+	}
+	mapper := NewMapper(&mockApi, "testCluster", "testWorkspace", "testRunId", nil)
+
+	results, err := mapper.MapWorkloads(context.Background(), models.Cluster{Name: "testCluster"})
+
+	assert.NoError(t, err)
+	// the pod without a controller is a workload of its own
+	assert.Len(t, results, 4)
+	assert.Equal(t, "debug", results[3].Workload.Name)
+	assert.Equal(t, "pod", results[3].Workload.WorkloadType)
+
+	deployment := results[0].Workload.WorkloadProperties.Containers
+	assert.Equal(t, "app", results[0].Workload.Name)
+	assert.Equal(t, []models.RunningImage{
+		{ImageID: "docker-pullable://app@" + digestV1, Digest: digestV1},
+		{ImageID: "docker-pullable://app@" + digestV2, Digest: digestV2},
+	}, deployment[0].RunningImages)
+	// the container that is not started yet reports no image ID
+	assert.Equal(t, []models.RunningImage{
+		{ImageID: "docker-pullable://envoy@" + digestV1, Digest: digestV1},
+	}, deployment[1].RunningImages)
+
+	cronJob := results[1].Workload.WorkloadProperties.Containers
+	assert.Equal(t, "backup", results[1].Workload.Name)
+	assert.Equal(t, []models.RunningImage{{ImageID: digestV1, Digest: digestV1}}, cronJob[0].RunningImages)
+	assert.Empty(t, cronJob[1].RunningImages)
+
+	statefulSet := results[2].Workload.WorkloadProperties.Containers
+	assert.Equal(t, "db", results[2].Workload.Name)
+	assert.Equal(t, []models.RunningImage{{ImageID: "docker://" + digestV2, Digest: digestV2}}, statefulSet[0].RunningImages)
+}
+
+func Test_AddRunningImages_noPods(t *testing.T) {
+	containers := models.Containers{{Name: "app"}}
+
+	AddRunningImages(containers, nil)
+
+	assert.NotNil(t, containers[0].RunningImages)
+	assert.Empty(t, containers[0].RunningImages)
+}
+
+func Test_AddRunningImages_runningPodsOnly(t *testing.T) {
+	containers := models.Containers{{Name: "app"}}
+	running := runningPod("app-1", nil, map[string]string{"app": digestV2})
+	succeeded := runningPod("app-0", nil, map[string]string{"app": digestV1})
+	succeeded.Status.Phase = corev1.PodSucceeded
+	pending := runningPod("app-2", nil, map[string]string{"app": digestV1})
+	pending.Status.Phase = corev1.PodPending
+
+	AddRunningImages(containers, []corev1.Pod{*succeeded, *running, *pending})
+
+	assert.Equal(t, []models.RunningImage{{ImageID: digestV2, Digest: digestV2}}, containers[0].RunningImages)
+}
+
+func Test_AddRunningImages_scaled(t *testing.T) {
+	one := models.Containers{{Name: "app"}}
+	three := models.Containers{{Name: "app"}}
+
+	AddRunningImages(one, []corev1.Pod{*runningPod("app-1", nil, map[string]string{"app": digestV1})})
+	AddRunningImages(three, []corev1.Pod{
+		*runningPod("app-1", nil, map[string]string{"app": digestV1}),
+		*runningPod("app-2", nil, map[string]string{"app": digestV1}),
+		*runningPod("app-3", nil, map[string]string{"app": digestV1}),
+	})
+
+	// scaling a workload does not change it
+	assert.Equal(t, one, three)
+}
+
+func Test_MapWorkloads_bareWorkloads(t *testing.T) {
+	logger.Init()
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "legacy"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:latest"}}},
+	}
+	mockApi := kubernetes.API{
+		Client: fake.NewSimpleClientset(
+			&batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "test", UID: "cronjob-uid"}},
+			// Jobs of CronJobs and ReplicaSets of Deployments are part of those workloads
+			&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "backup-28000000", Namespace: "test", UID: "scheduled-job-uid", OwnerReferences: controlledBy("CronJob", "backup", "cronjob-uid")}},
+			&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web-5d8f", Namespace: "test", UID: "owned-rs-uid", OwnerReferences: controlledBy("Deployment", "web", "deployment-uid")}},
+			&batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "test", UID: "job-uid"},
+				Spec:       batchv1.JobSpec{Parallelism: pointer.Int32(2), Template: template},
+			},
+			runningPod("migrate-x", controlledBy("Job", "migrate", "job-uid"), map[string]string{"app": digestV1}),
+			&appsv1.ReplicaSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "test", UID: "rs-uid"},
+				Spec:       appsv1.ReplicaSetSpec{Template: template},
+				Status:     appsv1.ReplicaSetStatus{Replicas: 3},
+			},
+			runningPod("legacy-a", controlledBy("ReplicaSet", "legacy", "rs-uid"), map[string]string{"app": digestV2}),
+			// pods of operators, CI runners and static pods of nodes are managed, not bare
+			runningPod("workflow-step", []metav1.OwnerReference{{APIVersion: "argoproj.io/v1alpha1", Kind: "Workflow", Name: "workflow", UID: "workflow-uid", Controller: pointer.Bool(true)}}, map[string]string{"app": digestV1}),
+			runningPod("kube-proxy-node-1", []metav1.OwnerReference{{APIVersion: "v1", Kind: "Node", Name: "node-1", UID: "node-uid", Controller: pointer.Bool(true)}}, map[string]string{"app": digestV1}),
+			&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "operated", Namespace: "test", UID: "operated-rs-uid", OwnerReferences: []metav1.OwnerReference{{APIVersion: "example.com/v1", Kind: "App", Name: "operated", UID: "app-uid", Controller: pointer.Bool(true)}}}},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "test", Labels: map[string]string{"app": "legacy"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "shell", Image: "busybox:1.36"}}},
+			},
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "test"},
+				Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "legacy"}},
+			},
+		),
+	}
+	mapper := NewMapper(&mockApi, "testCluster", "testWorkspace", "testRunId", nil)
+
+	results, err := mapper.MapWorkloads(context.Background(), models.Cluster{Name: "testCluster"})
+
+	assert.NoError(t, err)
+	workloads := map[string]models.Data{}
+	for _, result := range results {
+		workloads[result.Workload.WorkloadType+"/"+result.Workload.Name] = result
+	}
+	assert.ElementsMatch(t, []string{"cronjob/backup", "job/migrate", "replicaSet/legacy", "pod/migrate"}, keys(workloads))
+
+	job := workloads["job/migrate"]
+	assert.Equal(t, "2", job.Workload.WorkloadProperties.Replicas)
+	assert.Equal(t, "legacy", job.ServiceName)
+	assert.Equal(t, []models.RunningImage{{ImageID: digestV1, Digest: digestV1}}, job.Workload.WorkloadProperties.Containers[0].RunningImages)
+
+	replicaSet := workloads["replicaSet/legacy"]
+	assert.Equal(t, "3", replicaSet.Workload.WorkloadProperties.Replicas)
+	assert.Equal(t, []models.RunningImage{{ImageID: digestV2, Digest: digestV2}}, replicaSet.Workload.WorkloadProperties.Containers[0].RunningImages)
+
+	pod := workloads["pod/migrate"]
+	assert.Equal(t, "legacy", pod.ServiceName)
+	assert.Equal(t, "busybox:1.36", pod.Workload.WorkloadProperties.Containers[0].Image)
+
+	// a job and a pod of the same name are different items
+	assert.NotEqual(t, models.GenerateId("testWorkspace", "configId", job), models.GenerateId("testWorkspace", "configId", pod))
+}
+
+func keys(workloads map[string]models.Data) []string {
+	result := make([]string, 0, len(workloads))
+	for key := range workloads {
+		result = append(result, key)
+	}
+	return result
+}
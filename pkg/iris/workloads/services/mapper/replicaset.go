@@ -0,0 +1,54 @@
+package mapper
+
+import (
+	"strconv"
+	"time"
+
+	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// MapReplicaSetsEcst maps the ReplicaSets which are not created by a Deployment or another
+// controller
+func (m *workloadMapper) MapReplicaSetsEcst(cluster workload.Cluster, replicaSets *appsv1.ReplicaSetList, services *v1.ServiceList, pods *OwnedPods) ([]workload.Data, error) {
+	var allReplicaSets []workload.Data
+
+	for _, replicaSet := range replicaSets.Items {
+		if hasController(&replicaSet) {
+			continue
+		}
+		replicaSetServices := kubernetes.SelectingServices(services, replicaSet.Namespace, replicaSet.Spec.Template)
+		mappedReplicaSet := m.CreateReplicaSetEcst(cluster, replicaSet, serviceName(replicaSetServices))
+		mappedReplicaSet.Services = MapServices(replicaSetServices)
+		AddRunningImages(mappedReplicaSet.Workload.WorkloadProperties.Containers, pods.Of(&replicaSet))
+		allReplicaSets = append(allReplicaSets, mappedReplicaSet)
+	}
+
+	return allReplicaSets, nil
+}
+
+// CreateReplicaSetEcst create a data object that contains name, labels, ReplicaSet properties and more
+func (m *workloadMapper) CreateReplicaSetEcst(cluster workload.Cluster, replicaSet appsv1.ReplicaSet, service string) workload.Data {
+	return workload.Data{
+		Workload: workload.Workload{
+			Name:         replicaSet.Name,
+			WorkloadType: "replicaSet",
+			Labels:       replicaSet.ObjectMeta.Labels,
+			WorkloadProperties: workload.WorkloadProperties{
+				Replicas:   strconv.FormatInt(int64(replicaSet.Status.Replicas), 10),
+				Containers: MapContainers(replicaSet.Spec.Template),
+			},
+		},
+		Cluster: workload.Cluster{
+			Name:       cluster.Name,
+			OsImage:    cluster.OsImage,
+			NoOfNodes:  cluster.NoOfNodes,
+			K8sVersion: cluster.K8sVersion,
+		},
+		ServiceName:   service,
+		NamespaceName: replicaSet.Namespace,
+		Timestamp:     replicaSet.CreationTimestamp.UTC().Format(time.RFC3339),
+	}
+}
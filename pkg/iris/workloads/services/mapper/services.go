@@ -0,0 +1,46 @@
+package mapper
+
+import (
+	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+	v1 "k8s.io/api/core/v1"
+)
+
+// MapServices maps the services of a workload with their type, cluster IPs and ports
+func MapServices(services []v1.Service) []workload.Service {
+	if len(services) == 0 {
+		return nil
+	}
+	mapped := make([]workload.Service, 0, len(services))
+	for _, service := range services {
+		clusterIPs := service.Spec.ClusterIPs
+		if len(clusterIPs) == 0 && service.Spec.ClusterIP != "" {
+			clusterIPs = []string{service.Spec.ClusterIP}
+		}
+		ports := make([]workload.ServicePort, 0, len(service.Spec.Ports))
+		for _, port := range service.Spec.Ports {
+			ports = append(ports, workload.ServicePort{
+				Name:       port.Name,
+				Protocol:   string(port.Protocol),
+				Port:       port.Port,
+				TargetPort: port.TargetPort.String(),
+				NodePort:   port.NodePort,
+			})
+		}
+		mapped = append(mapped, workload.Service{
+			Name:       service.Name,
+			Type:       string(service.Spec.Type),
+			ClusterIPs: append([]string{}, clusterIPs...),
+			Ports:      ports,
+		})
+	}
+	return mapped
+}
+
+// serviceName is the name of the first service of a workload, kept as the service of the workload
+// for the results of earlier versions of the connector
+func serviceName(services []v1.Service) string {
+	if len(services) == 0 {
+		return ""
+	}
+	return services[0].Name
+}
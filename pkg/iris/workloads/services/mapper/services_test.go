@@ -0,0 +1,58 @@
+package mapper
+
+import (
+	"testing"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func selectingService(name string, namespace string, selector map[string]string) corev1.Service {
+	return corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       corev1.ServiceSpec{Selector: selector},
+	}
+}
+
+func TestServiceName(t *testing.T) {
+	services := []corev1.Service{
+		selectingService("shop", "web", map[string]string{"app": "shop"}),
+		selectingService("shop-headless", "web", map[string]string{"app": "shop"}),
+	}
+
+	assert.Equal(t, "shop", serviceName(services))
+	assert.Equal(t, "", serviceName(nil))
+}
+
+func TestMapServices(t *testing.T) {
+	clusterIP := selectingService("shop", "web", map[string]string{"app": "shop"})
+	clusterIP.Spec.Type = corev1.ServiceTypeNodePort
+	clusterIP.Spec.ClusterIP = "10.0.0.10"
+	clusterIP.Spec.ClusterIPs = []string{"10.0.0.10", "fd00::10"}
+	clusterIP.Spec.Ports = []corev1.ServicePort{
+		{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80, TargetPort: intstr.FromString("http"), NodePort: 30080},
+		{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090, TargetPort: intstr.FromInt32(9090)},
+	}
+	headless := selectingService("shop-headless", "web", map[string]string{"app": "shop"})
+	headless.Spec.Type = corev1.ServiceTypeClusterIP
+	headless.Spec.ClusterIP = corev1.ClusterIPNone
+
+	mapped := MapServices([]corev1.Service{clusterIP, headless})
+
+	assert.Equal(t, []models.Service{
+		{
+			Name:       "shop",
+			Type:       "NodePort",
+			ClusterIPs: []string{"10.0.0.10", "fd00::10"},
+			Ports: []models.ServicePort{
+				{Name: "http", Protocol: "TCP", Port: 80, TargetPort: "http", NodePort: 30080},
+				{Name: "metrics", Protocol: "TCP", Port: 9090, TargetPort: "9090"},
+			},
+		},
+		{Name: "shop-headless", Type: "ClusterIP", ClusterIPs: []string{"None"}, Ports: []models.ServicePort{}},
+	}, mapped)
+	assert.Nil(t, MapServices(nil))
+}
@@ -1,23 +1,22 @@
 package mapper
 
 import (
-	"reflect"
-	"strings"
 	"time"
 
 	workload "github.com/leanix/leanix-k8s-connector/pkg/iris/workloads/models"
-	"github.com/leanix/leanix-k8s-connector/pkg/logger"
+	"github.com/leanix/leanix-k8s-connector/pkg/kubernetes"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 )
 
-func (m *workloadMapper) MapStatefulSetsEcst(cluster workload.Cluster, statefulSets *appsv1.StatefulSetList, services *v1.ServiceList) ([]workload.Data, error) {
+func (m *workloadMapper) MapStatefulSetsEcst(cluster workload.Cluster, statefulSets *appsv1.StatefulSetList, services *v1.ServiceList, pods *OwnedPods) ([]workload.Data, error) {
 	var allStatefulSets []workload.Data
 
 	for _, statefulSet := range statefulSets.Items {
-		// Check if any service has the exact same selector labels and use this as the service related to the deployment
-		statefulSetService := ResolveK8sServiceForK8sStatefulSet(services, statefulSet)
-		mappedStatefulSet := m.CreateStatefulSetEcst(cluster, statefulSet, statefulSetService)
+		statefulSetServices := kubernetes.SelectingServices(services, statefulSet.Namespace, statefulSet.Spec.Template)
+		mappedStatefulSet := m.CreateStatefulSetEcst(cluster, statefulSet, serviceName(statefulSetServices))
+		mappedStatefulSet.Services = MapServices(statefulSetServices)
+		AddRunningImages(mappedStatefulSet.Workload.WorkloadProperties.Containers, pods.Of(&statefulSet))
 		allStatefulSets = append(allStatefulSets, mappedStatefulSet)
 	}
 
@@ -34,13 +33,7 @@ func (m *workloadMapper) CreateStatefulSetEcst(cluster workload.Cluster, statefu
 			WorkloadProperties: workload.WorkloadProperties{
 				Replicas:       string(statefulSet.Status.Replicas),
 				UpdateStrategy: string(statefulSet.Spec.UpdateStrategy.Type),
-				Containers: workload.Containers{
-					Name:        statefulSet.Spec.Template.Spec.Containers[0].Name,
-					Image:       strings.Split(statefulSet.Spec.Template.Spec.Containers[0].Image, ":")[0],
-					Port:        statefulSet.Spec.Template.Spec.Containers[0].Ports,
-					K8sLimits:   CreateK8sResources(statefulSet.Spec.Template.Spec.Containers[0].Resources.Limits),
-					K8sRequests: CreateK8sResources(statefulSet.Spec.Template.Spec.Containers[0].Resources.Requests),
-				},
+				Containers:     MapContainers(statefulSet.Spec.Template),
 			},
 		},
 		Cluster: workload.Cluster{
@@ -55,28 +48,3 @@ func (m *workloadMapper) CreateStatefulSetEcst(cluster workload.Cluster, statefu
 	}
 	return mappedDeployment
 }
-
-func ResolveK8sServiceForK8sStatefulSet(services *v1.ServiceList, statefulSet appsv1.StatefulSet) string {
-	statefulSetService := ""
-	if statefulSet.Spec.Selector != nil {
-
-		for _, service := range services.Items {
-			sharedLabelsStatefulSet := map[string]string{}
-			sharedLabelsService := map[string]string{}
-			for label := range service.Spec.Selector {
-				if _, ok := statefulSet.Spec.Selector.MatchLabels[label]; ok {
-					sharedLabelsStatefulSet[label] = statefulSet.Spec.Selector.MatchLabels[label]
-					sharedLabelsService[label] = service.Spec.Selector[label]
-				}
-			}
-
-			if len(sharedLabelsStatefulSet) != 0 && len(sharedLabelsService) != 0 && reflect.DeepEqual(sharedLabelsStatefulSet, sharedLabelsService) {
-				statefulSetService = service.Name
-				break
-			}
-		}
-	} else {
-		logger.Infof("StatefulSet %s has no selector labels", statefulSet.Name)
-	}
-	return statefulSetService
-}
@@ -1,15 +1,37 @@
 package kubernetes
 
 import (
+	"context"
 	"strings"
+	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
 
+const (
+	// DefaultPageSize is the number of items requested per list call
+	DefaultPageSize int64 = 500
+	// DefaultRequestTimeout bounds a single list request
+	DefaultRequestTimeout = 30 * time.Second
+	// maxListRestarts is the number of times paging starts over after the continue token expired
+	maxListRestarts = 3
+)
+
 // API is an optionated facade for the Kubernetes api
 type API struct {
 	Client kubernetes.Interface
+	// Dynamic lists the resources of CRDs, e.g. Gateway API routes. Without it they are not listed.
+	Dynamic dynamic.Interface
+	// PageSize is the number of items requested per list call, 0 lists all items at once
+	PageSize int64
+	// RequestTimeout bounds every page of a list call, 0 means no timeout
+	RequestTimeout time.Duration
+	informers      *Informers
+	snapshot       *Snapshot
 }
 
 // NewAPI creates a new Kubernetes api client
@@ -19,13 +41,70 @@ func NewAPI(config *rest.Config) (*API, error) {
 	if err != nil {
 		return nil, err
 	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
 	return &API{
-		Client: clientset,
+		Client:         clientset,
+		Dynamic:        dynamicClient,
+		PageSize:       DefaultPageSize,
+		RequestTimeout: DefaultRequestTimeout,
 	}, nil
 }
 
 type GetKubernetesAPI func(config *rest.Config) (*API, error)
 
+// WithInformers returns a copy of the api which answers list calls for the cached resources
+// from the given informers instead of the api server
+func (k *API) WithInformers(informers *Informers) *API {
+	return &API{
+		Client:         k.Client,
+		Dynamic:        k.Dynamic,
+		PageSize:       k.PageSize,
+		RequestTimeout: k.RequestTimeout,
+		informers:      informers,
+	}
+}
+
+// listPageFunc requests a single page and returns its items and the continue token for the next page
+type listPageFunc[T any] func(ctx context.Context, options metav1.ListOptions) ([]T, string, error)
+
+// list pages through all items of a list call. If the continue token expired because paging took
+// longer than the api server keeps the snapshot, paging starts over with the first page, up to
+// maxListRestarts times.
+func list[T any](ctx context.Context, k *API, options metav1.ListOptions, listPage listPageFunc[T]) ([]T, error) {
+	options.Limit = k.PageSize
+	var items []T
+	restarts := 0
+	for {
+		page, next, err := requestPage(ctx, k.RequestTimeout, options, listPage)
+		if apierrors.IsResourceExpired(err) && options.Continue != "" && restarts < maxListRestarts {
+			restarts++
+			items = nil
+			options.Continue = ""
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+		if next == "" {
+			return items, nil
+		}
+		options.Continue = next
+	}
+}
+
+func requestPage[T any](ctx context.Context, timeout time.Duration, options metav1.ListOptions, listPage listPageFunc[T]) ([]T, string, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return listPage(ctx, options)
+}
+
 // NamespaceBlacklistFieldSelector builds a Field Selector string to filter the response to not
 // include namespaces that belong to the blacklisted namespaces
 func NamespaceBlacklistFieldSelector(blacklistedNamespaces []string) string {
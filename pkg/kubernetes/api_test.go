@@ -1,9 +1,20 @@
 package kubernetes
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func TestBlacklistFieldSelector(t *testing.T) {
@@ -22,3 +33,123 @@ func TestPrefix(t *testing.T) {
 
 	assert.Equal(t, []string{"new-foo", "new-bar"}, r)
 }
+
+// pagedDeployments serves the deployments in pages of the requested limit, the continue token is
+// the index of the next item
+func pagedDeployments(t *testing.T, deployments []appsv1.Deployment, requests *[]metav1.ListOptions) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		options := action.(k8stesting.ListActionImpl).ListOptions
+		*requests = append(*requests, options)
+		start := 0
+		if options.Continue != "" {
+			var err error
+			start, err = strconv.Atoi(options.Continue)
+			assert.NoError(t, err)
+		}
+		end := len(deployments)
+		if options.Limit > 0 && start+int(options.Limit) < end {
+			end = start + int(options.Limit)
+		}
+		page := &appsv1.DeploymentList{Items: deployments[start:end]}
+		if end < len(deployments) {
+			page.Continue = strconv.Itoa(end)
+		}
+		return true, page, nil
+	}
+}
+
+func testDeployments(n int) []appsv1.Deployment {
+	deployments := make([]appsv1.Deployment, 0, n)
+	for i := 0; i < n; i++ {
+		deployments = append(deployments, appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("deployment-%d", i)}})
+	}
+	return deployments
+}
+
+func TestList_pages(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	var requests []metav1.ListOptions
+	client.PrependReactor("list", "deployments", pagedDeployments(t, testDeployments(5), &requests))
+	k := API{Client: client, PageSize: 2}
+
+	deployments, err := k.Deployments(context.Background(), "")
+
+	assert.NoError(t, err)
+	assert.Len(t, deployments.Items, 5)
+	assert.Equal(t, "deployment-4", deployments.Items[4].Name)
+	assert.Len(t, requests, 3)
+	assert.Equal(t, int64(2), requests[0].Limit)
+	assert.Equal(t, "", requests[0].Continue)
+	assert.Equal(t, "2", requests[1].Continue)
+	assert.Equal(t, "4", requests[2].Continue)
+}
+
+// expiringContinue lets the first expirations requests with a continue token fail as expired
+func expiringContinue(expirations int) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.(k8stesting.ListActionImpl).ListOptions.Continue != "" && expirations > 0 {
+			expirations--
+			return true, nil, apierrors.NewResourceExpired("continue token expired")
+		}
+		return false, nil, nil
+	}
+}
+
+func TestList_expiredContinue(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	var requests []metav1.ListOptions
+	client.PrependReactor("list", "deployments", pagedDeployments(t, testDeployments(5), &requests))
+	client.PrependReactor("list", "deployments", expiringContinue(1))
+	k := API{Client: client, PageSize: 2}
+
+	deployments, err := k.Deployments(context.Background(), "")
+
+	assert.NoError(t, err)
+	assert.Len(t, deployments.Items, 5)
+	assert.Equal(t, "deployment-4", deployments.Items[4].Name)
+	// the first page, then all pages again after the expired continue token
+	assert.Len(t, requests, 4)
+	for _, request := range requests {
+		assert.Equal(t, int64(2), request.Limit)
+	}
+	assert.Equal(t, []string{"", "", "2", "4"}, []string{requests[0].Continue, requests[1].Continue, requests[2].Continue, requests[3].Continue})
+}
+
+func TestList_expiredContinueRestartsBounded(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	var requests []metav1.ListOptions
+	client.PrependReactor("list", "deployments", pagedDeployments(t, testDeployments(5), &requests))
+	client.PrependReactor("list", "deployments", expiringContinue(maxListRestarts+1))
+	k := API{Client: client, PageSize: 2}
+
+	_, err := k.Deployments(context.Background(), "")
+
+	assert.True(t, apierrors.IsResourceExpired(err))
+	// the first page of the initial paging and of every restart
+	assert.Len(t, requests, maxListRestarts+1)
+}
+
+func TestList_requestTimeout(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	var deadline time.Time
+	client.PrependReactor("list", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("request failed")
+	})
+	k := API{
+		Client:         client,
+		RequestTimeout: time.Minute,
+	}
+	listPage := func(ctx context.Context, options metav1.ListOptions) ([]appsv1.Deployment, string, error) {
+		deadline, _ = ctx.Deadline()
+		page, err := k.Client.AppsV1().Deployments("").List(ctx, options)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Items, page.Continue, nil
+	}
+
+	_, err := list(context.Background(), &k, metav1.ListOptions{}, listPage)
+
+	assert.EqualError(t, err, "request failed")
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+}
@@ -0,0 +1,126 @@
+package kubernetes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+)
+
+// ClusterMapping maps a context of the kubeconfig to the cluster name and the Iris configurations
+// the cluster is scanned with
+type ClusterMapping struct {
+	Context string `json:"context"`
+	// Cluster overrides the cluster name of the configurations, empty keeps it
+	Cluster        string   `json:"cluster"`
+	Configurations []string `json:"configurations"`
+}
+
+// Cluster is a mapped context together with the rest config to reach it
+type Cluster struct {
+	ClusterMapping
+	Config *rest.Config
+}
+
+// ReadClusterMappings reads the list of cluster mappings from a YAML or JSON file
+func ReadClusterMappings(path string) ([]ClusterMapping, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var mappings []ClusterMapping
+	err = yaml.UnmarshalStrict(content, &mappings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cluster mappings %s: %w", path, err)
+	}
+	for i, mapping := range mappings {
+		if mapping.Context == "" || len(mapping.Configurations) == 0 {
+			return nil, fmt.Errorf("cluster mapping %d of %s needs a context and at least one configuration", i+1, path)
+		}
+	}
+	// clusters sharing a configuration are told apart by their cluster name
+	clusters := map[string]map[string]int{}
+	for i, mapping := range mappings {
+		for _, configuration := range mapping.Configurations {
+			if clusters[configuration] == nil {
+				clusters[configuration] = map[string]int{}
+			}
+			if other, ok := clusters[configuration][mapping.Cluster]; ok {
+				return nil, fmt.Errorf("cluster mappings %d and %d of %s share configuration '%s' and need distinct cluster names", other+1, i+1, path, configuration)
+			}
+			clusters[configuration][mapping.Cluster] = i
+		}
+	}
+	for configuration, names := range clusters {
+		if other, ok := names[""]; ok && len(names) > 1 {
+			return nil, fmt.Errorf("cluster mapping %d of %s shares configuration '%s' and needs a cluster name", other+1, path, configuration)
+		}
+	}
+	return mappings, nil
+}
+
+// LoadClusters builds the rest config of every mapped context. The kubeconfig is either a single
+// file or a directory of kubeconfig files, e.g. mounted Secrets, whose contexts are merged.
+func LoadClusters(kubeconfig string, mappings []ClusterMapping) ([]Cluster, error) {
+	files, err := kubeconfigFiles(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	rawConfig, err := (&clientcmd.ClientConfigLoadingRules{Precedence: files}).Load()
+	if err != nil {
+		return nil, err
+	}
+	clusters := make([]Cluster, 0, len(mappings))
+	for _, mapping := range mappings {
+		if _, ok := rawConfig.Contexts[mapping.Context]; !ok {
+			return nil, fmt.Errorf("context '%s' not found in kubeconfig %s", mapping.Context, kubeconfig)
+		}
+		config, err := clientcmd.NewNonInteractiveClientConfig(*rawConfig, mapping.Context, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build the client config of context '%s': %w", mapping.Context, err)
+		}
+		clusters = append(clusters, Cluster{ClusterMapping: mapping, Config: config})
+	}
+	return clusters, nil
+}
+
+// kubeconfigFiles returns the path itself for a file, or the files in a directory in lexical order.
+// Hidden entries are skipped, they hold the bookkeeping of mounted Secrets.
+func kubeconfigFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		file := filepath.Join(path, entry.Name())
+		// entries of mounted Secrets are symlinks, stat follows them
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no kubeconfig files found in %s", path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,123 @@
+package kubernetes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeKubeconfig(t *testing.T, path string, contexts ...string) {
+	content := "apiVersion: v1\nkind: Config\nclusters:\n"
+	for _, context := range contexts {
+		content += fmt.Sprintf("- name: %s\n  cluster:\n    server: https://%s.example.com\n", context, context)
+	}
+	content += "users:\n- name: connector\n  user:\n    token: secret\ncontexts:\n"
+	for _, context := range contexts {
+		content += fmt.Sprintf("- name: %s\n  context:\n    cluster: %s\n    user: connector\n", context, context)
+	}
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+func TestLoadClusters_directory(t *testing.T) {
+	dir := t.TempDir()
+	writeKubeconfig(t, filepath.Join(dir, "eu"), "prod-eu")
+	writeKubeconfig(t, filepath.Join(dir, "us"), "prod-us", "staging-us")
+	// the bookkeeping of mounted Secrets is skipped
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "..data"), 0700))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden"), []byte("not a kubeconfig"), 0600))
+
+	clusters, err := LoadClusters(dir, []ClusterMapping{
+		{Context: "prod-eu", Cluster: "eu", Configurations: []string{"team-a"}},
+		{Context: "staging-us", Configurations: []string{"team-a", "team-b"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, clusters, 2)
+	assert.Equal(t, "eu", clusters[0].Cluster)
+	assert.Equal(t, "https://prod-eu.example.com", clusters[0].Config.Host)
+	assert.Equal(t, "secret", clusters[0].Config.BearerToken)
+	assert.Equal(t, []string{"team-a", "team-b"}, clusters[1].Configurations)
+	assert.Equal(t, "https://staging-us.example.com", clusters[1].Config.Host)
+}
+
+func TestLoadClusters_unknownContext(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "kubeconfig")
+	writeKubeconfig(t, file, "prod-eu")
+
+	_, err := LoadClusters(file, []ClusterMapping{{Context: "prod-us", Configurations: []string{"team-a"}}})
+
+	assert.ErrorContains(t, err, "context 'prod-us' not found")
+}
+
+func TestReadClusterMappings(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "clusters.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(`
+- context: arn:aws:eks:eu-central-1:123456789012:cluster/prod
+  cluster: prod-eu
+  configurations: [team-a, team-b]
+`), 0600))
+
+	mappings, err := ReadClusterMappings(file)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []ClusterMapping{{
+		Context:        "arn:aws:eks:eu-central-1:123456789012:cluster/prod",
+		Cluster:        "prod-eu",
+		Configurations: []string{"team-a", "team-b"},
+	}}, mappings)
+}
+
+func TestReadClusterMappings_invalid(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "clusters.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("- context: prod\n"), 0600))
+
+	_, err := ReadClusterMappings(file)
+
+	assert.ErrorContains(t, err, "needs a context and at least one configuration")
+}
+
+func TestReadClusterMappings_sharedConfiguration(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "clusters.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(`
+- context: prod-eu
+  cluster: prod-eu
+  configurations: [team-a]
+- context: prod-us
+  cluster: prod-us
+  configurations: [team-a, team-b]
+`), 0600))
+
+	mappings, err := ReadClusterMappings(file)
+
+	assert.NoError(t, err)
+	assert.Len(t, mappings, 2)
+}
+
+func TestReadClusterMappings_sharedConfigurationWithoutClusterNames(t *testing.T) {
+	dir := t.TempDir()
+	sameName := filepath.Join(dir, "same-name.yaml")
+	assert.NoError(t, os.WriteFile(sameName, []byte(`
+- context: prod-eu
+  cluster: prod
+  configurations: [team-a]
+- context: prod-us
+  cluster: prod
+  configurations: [team-a]
+`), 0600))
+	withoutName := filepath.Join(dir, "without-name.yaml")
+	assert.NoError(t, os.WriteFile(withoutName, []byte(`
+- context: prod-eu
+  cluster: prod-eu
+  configurations: [team-a]
+- context: prod-us
+  configurations: [team-a]
+`), 0600))
+
+	_, err := ReadClusterMappings(sameName)
+	assert.ErrorContains(t, err, "share configuration 'team-a' and need distinct cluster names")
+	_, err = ReadClusterMappings(withoutName)
+	assert.ErrorContains(t, err, "cluster mapping 2 of "+withoutName+" shares configuration 'team-a' and needs a cluster name")
+}
@@ -0,0 +1,120 @@
+package kubernetes
+
+import (
+	"sort"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/container"
+	"github.com/leanix/leanix-k8s-connector/pkg/image"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultContainerAnnotation names the main container of a pod, see kubectl.kubernetes.io/default-container
+const DefaultContainerAnnotation = "kubectl.kubernetes.io/default-container"
+
+// PodContainer is a container of a pod template together with the role it plays in the pod
+type PodContainer struct {
+	Role  string
+	Name  string
+	Image string
+	// ImageReference is empty if the image could not be parsed
+	ImageReference image.Reference
+	Ports          []corev1.ContainerPort
+	Resources      corev1.ResourceRequirements
+}
+
+// PodContainers returns the init containers, containers and ephemeral containers of a pod template.
+// Init containers with restartPolicy Always are native sidecars. If the pod names its default
+// container, the other regular containers are reported as sidecars as well.
+func PodContainers(template corev1.PodTemplateSpec) []PodContainer {
+	spec := template.Spec
+	containers := make([]PodContainer, 0, len(spec.InitContainers)+len(spec.Containers)+len(spec.EphemeralContainers))
+	for _, c := range spec.InitContainers {
+		role := container.RoleInit
+		if c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			role = container.RoleSidecar
+		}
+		containers = append(containers, newPodContainer(role, c))
+	}
+	defaultContainer := template.Annotations[DefaultContainerAnnotation]
+	for _, c := range spec.Containers {
+		role := container.RoleMain
+		if defaultContainer != "" && defaultContainer != c.Name {
+			role = container.RoleSidecar
+		}
+		containers = append(containers, newPodContainer(role, c))
+	}
+	for _, c := range spec.EphemeralContainers {
+		containers = append(containers, newPodContainer(container.RoleEphemeral, corev1.Container(c.EphemeralContainerCommon)))
+	}
+	return containers
+}
+
+// MainContainer returns the first container with the main role
+func MainContainer(containers []PodContainer) (PodContainer, bool) {
+	for _, c := range containers {
+		if c.Role == container.RoleMain {
+			return c, true
+		}
+	}
+	return PodContainer{}, false
+}
+
+// roleOrder ranks the roles in the order containers are started
+var roleOrder = map[string]int{
+	container.RoleInit:      0,
+	container.RoleSidecar:   1,
+	container.RoleMain:      2,
+	container.RoleEphemeral: 3,
+}
+
+// CanonicalOrder returns the containers sorted by role and name with sorted ports, so reordering the
+// pod template does not change the mapped payload. Init containers keep their order, it is the
+// order they run in.
+func CanonicalOrder(containers []PodContainer) []PodContainer {
+	sorted := make([]PodContainer, len(containers))
+	copy(sorted, containers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if roleOrder[sorted[i].Role] != roleOrder[sorted[j].Role] {
+			return roleOrder[sorted[i].Role] < roleOrder[sorted[j].Role]
+		}
+		if sorted[i].Role == container.RoleInit {
+			return false
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	for i := range sorted {
+		sorted[i].Ports = sortedPorts(sorted[i].Ports)
+	}
+	return sorted
+}
+
+func sortedPorts(ports []corev1.ContainerPort) []corev1.ContainerPort {
+	if ports == nil {
+		return nil
+	}
+	sorted := make([]corev1.ContainerPort, len(ports))
+	copy(sorted, ports)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].ContainerPort != sorted[j].ContainerPort {
+			return sorted[i].ContainerPort < sorted[j].ContainerPort
+		}
+		if sorted[i].Protocol != sorted[j].Protocol {
+			return sorted[i].Protocol < sorted[j].Protocol
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+func newPodContainer(role string, c corev1.Container) PodContainer {
+	// an unparsable image is still reported with its raw name
+	reference, _ := image.Parse(c.Image)
+	return PodContainer{
+		Role:           role,
+		Name:           c.Name,
+		Image:          c.Image,
+		ImageReference: reference,
+		Ports:          c.Ports,
+		Resources:      c.Resources,
+	}
+}
@@ -0,0 +1,112 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/container"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodContainers(t *testing.T) {
+	always := corev1.ContainerRestartPolicyAlways
+	template := corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{
+				{Name: "migrate", Image: "migrate:1.0"},
+				{Name: "proxy", Image: "envoy:1.29", RestartPolicy: &always},
+			},
+			Containers: []corev1.Container{
+				{Name: "app", Image: "app:2.0"},
+				{Name: "log-shipper", Image: "fluent-bit:3.0"},
+			},
+			EphemeralContainers: []corev1.EphemeralContainer{
+				{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger", Image: "busybox"}},
+			},
+		},
+	}
+
+	containers := PodContainers(template)
+
+	assert.Len(t, containers, 5)
+	assert.Equal(t, container.RoleInit, containers[0].Role)
+	assert.Equal(t, container.RoleSidecar, containers[1].Role)
+	assert.Equal(t, container.RoleMain, containers[2].Role)
+	assert.Equal(t, container.RoleMain, containers[3].Role)
+	assert.Equal(t, container.RoleEphemeral, containers[4].Role)
+	assert.Equal(t, "busybox", containers[4].Image)
+}
+
+func TestPodContainers_defaultContainer(t *testing.T) {
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{DefaultContainerAnnotation: "app"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "istio-proxy", Image: "istio/proxyv2:1.22"},
+				{Name: "app", Image: "app:2.0"},
+			},
+		},
+	}
+
+	containers := PodContainers(template)
+	mainContainer, found := MainContainer(containers)
+
+	assert.Equal(t, container.RoleSidecar, containers[0].Role)
+	assert.Equal(t, container.RoleMain, containers[1].Role)
+	assert.True(t, found)
+	assert.Equal(t, "app", mainContainer.Name)
+}
+
+func TestPodContainers_noContainers(t *testing.T) {
+	containers := PodContainers(corev1.PodTemplateSpec{})
+	_, found := MainContainer(containers)
+
+	assert.Empty(t, containers)
+	assert.False(t, found)
+}
+
+func TestPodContainers_imageReference(t *testing.T) {
+	template := corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "app", Image: "registry:5000/team/app:1.2"},
+				{Name: "invalid", Image: ""},
+			},
+		},
+	}
+
+	containers := PodContainers(template)
+
+	assert.Equal(t, "registry:5000", containers[0].ImageReference.Registry)
+	assert.Equal(t, "team/app", containers[0].ImageReference.Repository)
+	assert.Equal(t, "1.2", containers[0].ImageReference.Tag)
+	assert.Empty(t, containers[1].ImageReference.Repository)
+}
+
+func TestCanonicalOrder(t *testing.T) {
+	containers := []PodContainer{
+		{Name: "worker", Role: container.RoleMain},
+		{Name: "setup", Role: container.RoleInit},
+		{Name: "migrate", Role: container.RoleInit},
+		{Name: "debugger", Role: container.RoleEphemeral},
+		{Name: "app", Role: container.RoleMain, Ports: []corev1.ContainerPort{
+			{Name: "metrics", ContainerPort: 9090},
+			{Name: "dns-udp", ContainerPort: 53, Protocol: corev1.ProtocolUDP},
+			{Name: "dns-tcp", ContainerPort: 53, Protocol: corev1.ProtocolTCP},
+		}},
+		{Name: "proxy", Role: container.RoleSidecar},
+	}
+
+	sorted := CanonicalOrder(containers)
+
+	names := make([]string, 0)
+	for _, c := range sorted {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"setup", "migrate", "proxy", "app", "worker", "debugger"}, names)
+	assert.Equal(t, []string{"dns-tcp", "dns-udp", "metrics"}, []string{sorted[3].Ports[0].Name, sorted[3].Ports[1].Name, sorted[3].Ports[2].Name})
+	assert.Equal(t, "metrics", containers[4].Ports[0].Name)
+}
@@ -8,10 +8,21 @@ import (
 )
 
 // Cronjobs gets the list of cronjobs in a namespace
-func (k *API) CronJobs(namespace string) (*v1.CronJobList, error) {
-	cronJobs, err := k.Client.BatchV1().CronJobs(namespace).List(context.Background(), metav1.ListOptions{})
+func (k *API) CronJobs(ctx context.Context, namespace string) (*v1.CronJobList, error) {
+	if k.informers != nil {
+		return k.informers.cronJobList(namespace)
+	}
+	items, err := snapshotList(ctx, k, "cronjobs", namespace, func(ctx context.Context, namespace string) ([]v1.CronJob, error) {
+		return list(ctx, k, metav1.ListOptions{}, func(ctx context.Context, options metav1.ListOptions) ([]v1.CronJob, string, error) {
+			page, err := k.Client.BatchV1().CronJobs(namespace).List(ctx, options)
+			if err != nil {
+				return nil, "", err
+			}
+			return page.Items, page.Continue, nil
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
-	return cronJobs, nil
+	return &v1.CronJobList{Items: items}, nil
 }
@@ -0,0 +1,34 @@
+package kubernetes
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// CustomResources gets the list of objects of a custom resource in a namespace with the dynamic
+// client. An api without a dynamic client and clusters without the CRD return an empty list.
+func (k *API) CustomResources(ctx context.Context, resource schema.GroupVersionResource, namespace string) (*unstructured.UnstructuredList, error) {
+	if k.Dynamic == nil {
+		return &unstructured.UnstructuredList{}, nil
+	}
+	items, err := snapshotList(ctx, k, resource.String(), namespace, func(ctx context.Context, namespace string) ([]unstructured.Unstructured, error) {
+		return list(ctx, k, metav1.ListOptions{}, func(ctx context.Context, options metav1.ListOptions) ([]unstructured.Unstructured, string, error) {
+			page, err := k.Dynamic.Resource(resource).Namespace(namespace).List(ctx, options)
+			if apierrors.IsNotFound(err) {
+				return nil, "", nil
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			return page.Items, page.GetContinue(), nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &unstructured.UnstructuredList{Items: items}, nil
+}
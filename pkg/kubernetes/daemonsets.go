@@ -8,10 +8,21 @@ import (
 )
 
 // DaemonSets gets the list of daemonSets in a namespace
-func (k *API) DaemonSets(namespace string) (*v1.DaemonSetList, error) {
-	daemonSets, err := k.Client.AppsV1().DaemonSets(namespace).List(context.Background(), metav1.ListOptions{})
+func (k *API) DaemonSets(ctx context.Context, namespace string) (*v1.DaemonSetList, error) {
+	if k.informers != nil {
+		return k.informers.daemonSetList(namespace)
+	}
+	items, err := snapshotList(ctx, k, "daemonsets", namespace, func(ctx context.Context, namespace string) ([]v1.DaemonSet, error) {
+		return list(ctx, k, metav1.ListOptions{}, func(ctx context.Context, options metav1.ListOptions) ([]v1.DaemonSet, string, error) {
+			page, err := k.Client.AppsV1().DaemonSets(namespace).List(ctx, options)
+			if err != nil {
+				return nil, "", err
+			}
+			return page.Items, page.Continue, nil
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
-	return daemonSets, nil
+	return &v1.DaemonSetList{Items: items}, nil
 }
@@ -8,10 +8,21 @@ import (
 )
 
 // Deployments gets the list of deployments in a namespace
-func (k *API) Deployments(namespace string) (*v1.DeploymentList, error) {
-	deployments, err := k.Client.AppsV1().Deployments(namespace).List(context.Background(), metav1.ListOptions{})
+func (k *API) Deployments(ctx context.Context, namespace string) (*v1.DeploymentList, error) {
+	if k.informers != nil {
+		return k.informers.deploymentList(namespace)
+	}
+	items, err := snapshotList(ctx, k, "deployments", namespace, func(ctx context.Context, namespace string) ([]v1.Deployment, error) {
+		return list(ctx, k, metav1.ListOptions{}, func(ctx context.Context, options metav1.ListOptions) ([]v1.Deployment, string, error) {
+			page, err := k.Client.AppsV1().Deployments(namespace).List(ctx, options)
+			if err != nil {
+				return nil, "", err
+			}
+			return page.Items, page.Continue, nil
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
-	return deployments, nil
+	return &v1.DeploymentList{Items: items}, nil
 }
@@ -1,6 +1,8 @@
 package kubernetes
 
 import (
+	"context"
+
 	"testing"
 	"time"
 
@@ -59,7 +61,7 @@ func TestDeployments(t *testing.T) {
 		Client: fake.NewSimpleClientset(dummyDeployments...),
 	}
 
-	deployments, err := k.Deployments("deployment-1-namespace")
+	deployments, err := k.Deployments(context.Background(), "deployment-1-namespace")
 	if err != nil {
 		t.Error(err)
 	}
@@ -0,0 +1,154 @@
+package kubernetes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/logger"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GatewayGroup is the API group of the Gateway API
+const GatewayGroup = "gateway.networking.k8s.io"
+
+// HTTPRouteResource is the Gateway API resource of HTTPRoutes
+var HTTPRouteResource = schema.GroupVersionResource{Group: GatewayGroup, Version: "v1", Resource: "httproutes"}
+
+// ReferenceGrantResource is the Gateway API resource of ReferenceGrants
+var ReferenceGrantResource = schema.GroupVersionResource{Group: GatewayGroup, Version: "v1beta1", Resource: "referencegrants"}
+
+// forbiddenGatewayResources are the Gateway API resources the connector was not allowed to list, so
+// each of them is logged once only
+var forbiddenGatewayResources sync.Map
+
+// gatewayResources lists the objects of a Gateway API resource. Connectors whose role does not
+// grant the Gateway API get an empty list, like clusters without it, so the scan carries on
+// without the exposures of the resource.
+func (k *API) gatewayResources(ctx context.Context, resource schema.GroupVersionResource, namespace string) (*unstructured.UnstructuredList, error) {
+	objects, err := k.CustomResources(ctx, resource, namespace)
+	if apierrors.IsForbidden(err) {
+		if _, logged := forbiddenGatewayResources.LoadOrStore(resource, true); !logged {
+			logger.Infof("Skipping %s, the connector is not allowed to list them: %v", resource.Resource, err)
+		}
+		return &unstructured.UnstructuredList{}, nil
+	}
+	return objects, err
+}
+
+// HTTPRoute holds the fields of a Gateway API HTTPRoute the connector maps. The Gateway API is not
+// part of client-go, the routes are read with the dynamic client.
+type HTTPRoute struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              HTTPRouteSpec `json:"spec"`
+}
+
+type HTTPRouteSpec struct {
+	ParentRefs []ParentReference `json:"parentRefs,omitempty"`
+	Hostnames  []string          `json:"hostnames,omitempty"`
+	Rules      []HTTPRouteRule   `json:"rules,omitempty"`
+}
+
+// ParentReference is the Gateway the route is attached to, unless group and kind say otherwise
+type ParentReference struct {
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+type HTTPRouteRule struct {
+	Matches     []HTTPRouteMatch `json:"matches,omitempty"`
+	BackendRefs []BackendRef     `json:"backendRefs,omitempty"`
+}
+
+type HTTPRouteMatch struct {
+	Path *HTTPPathMatch `json:"path,omitempty"`
+}
+
+type HTTPPathMatch struct {
+	Type  string `json:"type,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// BackendRef references the backend of a rule, a Service unless group and kind say otherwise
+type BackendRef struct {
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Port      int32  `json:"port,omitempty"`
+}
+
+type HTTPRouteList struct {
+	Items []HTTPRoute
+}
+
+// HTTPRoutes gets the list of Gateway API HTTPRoutes in a namespace. Clusters without the Gateway
+// API and connectors not allowed to read it return an empty list. The routes are always read from the api server, informers do not
+// cache them.
+func (k *API) HTTPRoutes(ctx context.Context, namespace string) (*HTTPRouteList, error) {
+	objects, err := k.gatewayResources(ctx, HTTPRouteResource, namespace)
+	if err != nil {
+		return nil, err
+	}
+	routes := make([]HTTPRoute, len(objects.Items))
+	for i := range objects.Items {
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(objects.Items[i].Object, &routes[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &HTTPRouteList{Items: routes}, nil
+}
+
+// ReferenceGrant allows the routes of other namespaces to reference objects in its namespace
+type ReferenceGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ReferenceGrantSpec `json:"spec"`
+}
+
+type ReferenceGrantSpec struct {
+	From []ReferenceGrantFrom `json:"from"`
+	To   []ReferenceGrantTo   `json:"to"`
+}
+
+// ReferenceGrantFrom is the kind of objects in a namespace allowed to reference
+type ReferenceGrantFrom struct {
+	Group     string `json:"group"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+}
+
+// ReferenceGrantTo is the kind of objects allowed to be referenced, all of them without a name
+type ReferenceGrantTo struct {
+	Group string `json:"group"`
+	Kind  string `json:"kind"`
+	Name  string `json:"name,omitempty"`
+}
+
+type ReferenceGrantList struct {
+	Items []ReferenceGrant
+}
+
+// ReferenceGrants gets the list of Gateway API ReferenceGrants in a namespace. Clusters without the
+// Gateway API and connectors not allowed to read it return an empty list.
+func (k *API) ReferenceGrants(ctx context.Context, namespace string) (*ReferenceGrantList, error) {
+	objects, err := k.gatewayResources(ctx, ReferenceGrantResource, namespace)
+	if err != nil {
+		return nil, err
+	}
+	grants := make([]ReferenceGrant, len(objects.Items))
+	for i := range objects.Items {
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(objects.Items[i].Object, &grants[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &ReferenceGrantList{Items: grants}, nil
+}
@@ -0,0 +1,113 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func httpRoute(namespace string, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "HTTPRoute",
+		"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+		"spec":       spec,
+	}}
+}
+
+func newDynamicClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{HTTPRouteResource: "HTTPRouteList", ReferenceGrantResource: "ReferenceGrantList"}, objects...)
+}
+
+func TestHTTPRoutes(t *testing.T) {
+	client := newDynamicClient(
+		httpRoute("web", "shop", map[string]interface{}{
+			"parentRefs": []interface{}{map[string]interface{}{"name": "public", "namespace": "gateways"}},
+			"hostnames":  []interface{}{"shop.example.com"},
+			"rules": []interface{}{map[string]interface{}{
+				"matches":     []interface{}{map[string]interface{}{"path": map[string]interface{}{"type": "PathPrefix", "value": "/cart"}}},
+				"backendRefs": []interface{}{map[string]interface{}{"name": "shop", "port": int64(8080)}},
+			}},
+		}),
+		httpRoute("other", "admin", map[string]interface{}{}),
+	)
+	k := &API{Dynamic: client}
+
+	routes, err := k.HTTPRoutes(context.Background(), "web")
+
+	assert.NoError(t, err)
+	assert.Len(t, routes.Items, 1)
+	route := routes.Items[0]
+	assert.Equal(t, "shop", route.Name)
+	assert.Equal(t, []string{"shop.example.com"}, route.Spec.Hostnames)
+	assert.Equal(t, "gateways", route.Spec.ParentRefs[0].Namespace)
+	assert.Equal(t, "/cart", route.Spec.Rules[0].Matches[0].Path.Value)
+	assert.Equal(t, BackendRef{Name: "shop", Port: 8080}, route.Spec.Rules[0].BackendRefs[0])
+}
+
+func TestHTTPRoutes_gatewayApiNotInstalled(t *testing.T) {
+	client := newDynamicClient()
+	client.PrependReactor("list", "httproutes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(HTTPRouteResource.GroupResource(), "")
+	})
+	k := &API{Dynamic: client}
+
+	routes, err := k.HTTPRoutes(context.Background(), "")
+
+	assert.NoError(t, err)
+	assert.Empty(t, routes.Items)
+}
+
+func TestHTTPRoutes_forbidden(t *testing.T) {
+	logger.Init()
+	client := newDynamicClient()
+	client.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(action.GetResource().GroupResource(), "", errors.New("RBAC: access denied"))
+	})
+	k := &API{Dynamic: client}
+
+	routes, err := k.HTTPRoutes(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Empty(t, routes.Items)
+
+	grants, err := k.ReferenceGrants(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Empty(t, grants.Items)
+}
+
+func TestHTTPRoutes_noDynamicClient(t *testing.T) {
+	routes, err := (&API{}).HTTPRoutes(context.Background(), "")
+
+	assert.NoError(t, err)
+	assert.Empty(t, routes.Items)
+}
+
+func TestReferenceGrants(t *testing.T) {
+	client := newDynamicClient(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1beta1",
+		"kind":       "ReferenceGrant",
+		"metadata":   map[string]interface{}{"name": "routes-from-gateways", "namespace": "web"},
+		"spec": map[string]interface{}{
+			"from": []interface{}{map[string]interface{}{"group": GatewayGroup, "kind": "HTTPRoute", "namespace": "gateways"}},
+			"to":   []interface{}{map[string]interface{}{"group": "", "kind": "Service", "name": "shop"}},
+		},
+	}})
+	k := &API{Dynamic: client}
+
+	grants, err := k.ReferenceGrants(context.Background(), "")
+
+	assert.NoError(t, err)
+	assert.Len(t, grants.Items, 1)
+	assert.Equal(t, "web", grants.Items[0].Namespace)
+	assert.Equal(t, []ReferenceGrantFrom{{Group: GatewayGroup, Kind: "HTTPRoute", Namespace: "gateways"}}, grants.Items[0].Spec.From)
+	assert.Equal(t, []ReferenceGrantTo{{Kind: "Service", Name: "shop"}}, grants.Items[0].Spec.To)
+}
@@ -0,0 +1,343 @@
+package kubernetes
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	networkinglisters "k8s.io/client-go/listers/networking/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Informers keeps an in-memory copy of the resources the connector maps, kept up to date by
+// client-go shared informers.
+type Informers struct {
+	factory      informers.SharedInformerFactory
+	handlers     []cache.SharedIndexInformer
+	deployments  appslisters.DeploymentLister
+	statefulSets appslisters.StatefulSetLister
+	daemonSets   appslisters.DaemonSetLister
+	cronJobs     batchlisters.CronJobLister
+	services     corelisters.ServiceLister
+	namespaces   corelisters.NamespaceLister
+	ingresses    networkinglisters.IngressLister
+	// pods, replicaSets and jobs are only cached, their frequent status changes do not notify handlers.
+	// Bare ones, mapped as workloads of their own, are picked up by the next resync.
+	pods        corelisters.PodLister
+	replicaSets appslisters.ReplicaSetLister
+	jobs        batchlisters.JobLister
+}
+
+// NewInformers creates shared informers for deployments, statefulsets, daemonsets, cronjobs,
+// services, namespaces and ingresses, and caches for the pods, replicasets and jobs owned by them.
+// The informers are not started until Start is called.
+func NewInformers(client kubernetes.Interface, resync time.Duration) *Informers {
+	factory := informers.NewSharedInformerFactory(client, resync)
+	i := &Informers{
+		factory:      factory,
+		deployments:  factory.Apps().V1().Deployments().Lister(),
+		statefulSets: factory.Apps().V1().StatefulSets().Lister(),
+		daemonSets:   factory.Apps().V1().DaemonSets().Lister(),
+		cronJobs:     factory.Batch().V1().CronJobs().Lister(),
+		services:     factory.Core().V1().Services().Lister(),
+		namespaces:   factory.Core().V1().Namespaces().Lister(),
+		ingresses:    factory.Networking().V1().Ingresses().Lister(),
+		pods:         factory.Core().V1().Pods().Lister(),
+		replicaSets:  factory.Apps().V1().ReplicaSets().Lister(),
+		jobs:         factory.Batch().V1().Jobs().Lister(),
+	}
+	i.handlers = []cache.SharedIndexInformer{
+		factory.Apps().V1().Deployments().Informer(),
+		factory.Apps().V1().StatefulSets().Informer(),
+		factory.Apps().V1().DaemonSets().Informer(),
+		factory.Batch().V1().CronJobs().Informer(),
+		factory.Core().V1().Services().Informer(),
+		factory.Core().V1().Namespaces().Informer(),
+		factory.Networking().V1().Ingresses().Informer(),
+	}
+	return i
+}
+
+// AddEventHandler registers the handler on all watched resources
+func (i *Informers) AddEventHandler(handler cache.ResourceEventHandler) error {
+	for _, informer := range i.handlers {
+		if _, err := informer.AddEventHandler(handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start starts the informers and blocks until their caches are synced or stopCh is closed
+func (i *Informers) Start(stopCh <-chan struct{}) error {
+	i.factory.Start(stopCh)
+	for informerType, synced := range i.factory.WaitForCacheSync(stopCh) {
+		if !synced {
+			return fmt.Errorf("failed to sync informer cache for %v", informerType)
+		}
+	}
+	return nil
+}
+
+// SharedInformers returns a GetKubernetesAPI which creates the api and its informers only once and
+// hands out the same cached api to every caller, e.g. to the watches of several configurations. The
+// informers are started on the first call and run until stopCh is closed. An api which already has
+// informers is handed out as it is.
+func SharedInformers(getKubernetesAPI GetKubernetesAPI, stopCh <-chan struct{}) GetKubernetesAPI {
+	var once sync.Once
+	var api *API
+	var err error
+	return func(config *rest.Config) (*API, error) {
+		once.Do(func() {
+			api, err = getKubernetesAPI(config)
+			if err != nil || api.informers != nil {
+				return
+			}
+			informers := NewInformers(api.Client, 0)
+			err = informers.Start(stopCh)
+			if err == nil {
+				api = api.WithInformers(informers)
+			}
+		})
+		return api, err
+	}
+}
+
+// Informers returns the informers the api answers list calls from, nil for an api without informers
+func (k *API) Informers() *Informers {
+	return k.informers
+}
+
+func (i *Informers) deploymentList(namespace string) (*appsv1.DeploymentList, error) {
+	var items []*appsv1.Deployment
+	var err error
+	if namespace == "" {
+		items, err = i.deployments.List(labels.Everything())
+	} else {
+		items, err = i.deployments.Deployments(namespace).List(labels.Everything())
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := &appsv1.DeploymentList{Items: make([]appsv1.Deployment, 0, len(items))}
+	for _, item := range items {
+		list.Items = append(list.Items, *item.DeepCopy())
+	}
+	sort.Slice(list.Items, func(a, b int) bool {
+		return namespacedName(&list.Items[a]) < namespacedName(&list.Items[b])
+	})
+	return list, nil
+}
+
+func (i *Informers) statefulSetList(namespace string) (*appsv1.StatefulSetList, error) {
+	var items []*appsv1.StatefulSet
+	var err error
+	if namespace == "" {
+		items, err = i.statefulSets.List(labels.Everything())
+	} else {
+		items, err = i.statefulSets.StatefulSets(namespace).List(labels.Everything())
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := &appsv1.StatefulSetList{Items: make([]appsv1.StatefulSet, 0, len(items))}
+	for _, item := range items {
+		list.Items = append(list.Items, *item.DeepCopy())
+	}
+	sort.Slice(list.Items, func(a, b int) bool {
+		return namespacedName(&list.Items[a]) < namespacedName(&list.Items[b])
+	})
+	return list, nil
+}
+
+func (i *Informers) daemonSetList(namespace string) (*appsv1.DaemonSetList, error) {
+	var items []*appsv1.DaemonSet
+	var err error
+	if namespace == "" {
+		items, err = i.daemonSets.List(labels.Everything())
+	} else {
+		items, err = i.daemonSets.DaemonSets(namespace).List(labels.Everything())
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := &appsv1.DaemonSetList{Items: make([]appsv1.DaemonSet, 0, len(items))}
+	for _, item := range items {
+		list.Items = append(list.Items, *item.DeepCopy())
+	}
+	sort.Slice(list.Items, func(a, b int) bool {
+		return namespacedName(&list.Items[a]) < namespacedName(&list.Items[b])
+	})
+	return list, nil
+}
+
+func (i *Informers) cronJobList(namespace string) (*batchv1.CronJobList, error) {
+	var items []*batchv1.CronJob
+	var err error
+	if namespace == "" {
+		items, err = i.cronJobs.List(labels.Everything())
+	} else {
+		items, err = i.cronJobs.CronJobs(namespace).List(labels.Everything())
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := &batchv1.CronJobList{Items: make([]batchv1.CronJob, 0, len(items))}
+	for _, item := range items {
+		list.Items = append(list.Items, *item.DeepCopy())
+	}
+	sort.Slice(list.Items, func(a, b int) bool {
+		return namespacedName(&list.Items[a]) < namespacedName(&list.Items[b])
+	})
+	return list, nil
+}
+
+func (i *Informers) serviceList(namespace string) (*corev1.ServiceList, error) {
+	var items []*corev1.Service
+	var err error
+	if namespace == "" {
+		items, err = i.services.List(labels.Everything())
+	} else {
+		items, err = i.services.Services(namespace).List(labels.Everything())
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := &corev1.ServiceList{Items: make([]corev1.Service, 0, len(items))}
+	for _, item := range items {
+		list.Items = append(list.Items, *item.DeepCopy())
+	}
+	sort.Slice(list.Items, func(a, b int) bool {
+		return namespacedName(&list.Items[a]) < namespacedName(&list.Items[b])
+	})
+	return list, nil
+}
+
+func (i *Informers) namespaceList(blacklistedNamespaces []string) (*corev1.NamespaceList, error) {
+	items, err := i.namespaces.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	list := &corev1.NamespaceList{Items: make([]corev1.Namespace, 0, len(items))}
+	for _, item := range items {
+		if contains(blacklistedNamespaces, item.Name) {
+			continue
+		}
+		list.Items = append(list.Items, *item.DeepCopy())
+	}
+	sort.Slice(list.Items, func(a, b int) bool {
+		return namespacedName(&list.Items[a]) < namespacedName(&list.Items[b])
+	})
+	return list, nil
+}
+
+func (i *Informers) ingressList(namespace string) (*networkingv1.IngressList, error) {
+	var items []*networkingv1.Ingress
+	var err error
+	if namespace == "" {
+		items, err = i.ingresses.List(labels.Everything())
+	} else {
+		items, err = i.ingresses.Ingresses(namespace).List(labels.Everything())
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := &networkingv1.IngressList{Items: make([]networkingv1.Ingress, 0, len(items))}
+	for _, item := range items {
+		list.Items = append(list.Items, *item.DeepCopy())
+	}
+	sort.Slice(list.Items, func(a, b int) bool {
+		return namespacedName(&list.Items[a]) < namespacedName(&list.Items[b])
+	})
+	return list, nil
+}
+
+func (i *Informers) podList(namespace string) (*corev1.PodList, error) {
+	var items []*corev1.Pod
+	var err error
+	if namespace == "" {
+		items, err = i.pods.List(labels.Everything())
+	} else {
+		items, err = i.pods.Pods(namespace).List(labels.Everything())
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := &corev1.PodList{Items: make([]corev1.Pod, 0, len(items))}
+	for _, item := range items {
+		list.Items = append(list.Items, *item.DeepCopy())
+	}
+	sort.Slice(list.Items, func(a, b int) bool {
+		return namespacedName(&list.Items[a]) < namespacedName(&list.Items[b])
+	})
+	return list, nil
+}
+
+func (i *Informers) replicaSetList(namespace string) (*appsv1.ReplicaSetList, error) {
+	var items []*appsv1.ReplicaSet
+	var err error
+	if namespace == "" {
+		items, err = i.replicaSets.List(labels.Everything())
+	} else {
+		items, err = i.replicaSets.ReplicaSets(namespace).List(labels.Everything())
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := &appsv1.ReplicaSetList{Items: make([]appsv1.ReplicaSet, 0, len(items))}
+	for _, item := range items {
+		list.Items = append(list.Items, *item.DeepCopy())
+	}
+	sort.Slice(list.Items, func(a, b int) bool {
+		return namespacedName(&list.Items[a]) < namespacedName(&list.Items[b])
+	})
+	return list, nil
+}
+
+func (i *Informers) jobList(namespace string) (*batchv1.JobList, error) {
+	var items []*batchv1.Job
+	var err error
+	if namespace == "" {
+		items, err = i.jobs.List(labels.Everything())
+	} else {
+		items, err = i.jobs.Jobs(namespace).List(labels.Everything())
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := &batchv1.JobList{Items: make([]batchv1.Job, 0, len(items))}
+	for _, item := range items {
+		list.Items = append(list.Items, *item.DeepCopy())
+	}
+	sort.Slice(list.Items, func(a, b int) bool {
+		return namespacedName(&list.Items[a]) < namespacedName(&list.Items[b])
+	})
+	return list, nil
+}
+
+func contains(l []string, s string) bool {
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// namespacedName is used to return cached objects in the same order as the api server does
+func namespacedName(object metav1.Object) string {
+	return object.GetNamespace() + "/" + object.GetName()
+}
@@ -0,0 +1,79 @@
+package kubernetes
+
+import (
+	"context"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestInformers(t *testing.T) {
+	dummyObjects := []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "test-deployment-2", Namespace: "team-a"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "test-deployment-1", Namespace: "team-a"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "test-deployment-3", Namespace: "team-b"}},
+	}
+	client := fake.NewSimpleClientset(dummyObjects...)
+	informers := NewInformers(client, 0)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	err := informers.Start(stopCh)
+	assert.NoError(t, err)
+
+	k := (&API{Client: client}).WithInformers(informers)
+
+	deployments, err := k.Deployments(context.Background(), "team-a")
+	assert.NoError(t, err)
+	assert.Len(t, deployments.Items, 2)
+	assert.Equal(t, "test-deployment-1", deployments.Items[0].Name)
+	assert.Equal(t, "test-deployment-2", deployments.Items[1].Name)
+
+	allDeployments, err := k.Deployments(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Len(t, allDeployments.Items, 3)
+
+	namespaces, err := k.Namespaces(context.Background(), []string{"kube-system"})
+	assert.NoError(t, err)
+	assert.Len(t, namespaces.Items, 2)
+	assert.Equal(t, "team-a", namespaces.Items[0].Name)
+	assert.Equal(t, "team-b", namespaces.Items[1].Name)
+}
+
+func TestSharedInformers(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}})
+	created := 0
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	getKubernetesAPI := SharedInformers(func(*rest.Config) (*API, error) {
+		created++
+		return &API{Client: client}, nil
+	}, stopCh)
+
+	first, err := getKubernetesAPI(nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, first.Informers())
+	for i := 0; i < 2; i++ {
+		k, err := getKubernetesAPI(nil)
+		assert.NoError(t, err)
+		assert.Same(t, first.Informers(), k.Informers())
+		namespaces, err := k.Namespaces(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Len(t, namespaces.Items, 1)
+	}
+	assert.Equal(t, 1, created)
+
+	// an api with informers is handed out as it is
+	k, err := SharedInformers(func(*rest.Config) (*API, error) { return first, nil }, stopCh)(nil)
+	assert.NoError(t, err)
+	assert.Same(t, first, k)
+}
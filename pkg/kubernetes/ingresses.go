@@ -0,0 +1,28 @@
+package kubernetes
+
+import (
+	"context"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Ingresses gets the list of ingresses in a namespace
+func (k *API) Ingresses(ctx context.Context, namespace string) (*networkingv1.IngressList, error) {
+	if k.informers != nil {
+		return k.informers.ingressList(namespace)
+	}
+	items, err := snapshotList(ctx, k, "ingresses", namespace, func(ctx context.Context, namespace string) ([]networkingv1.Ingress, error) {
+		return list(ctx, k, metav1.ListOptions{}, func(ctx context.Context, options metav1.ListOptions) ([]networkingv1.Ingress, string, error) {
+			page, err := k.Client.NetworkingV1().Ingresses(namespace).List(ctx, options)
+			if err != nil {
+				return nil, "", err
+			}
+			return page.Items, page.Continue, nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &networkingv1.IngressList{Items: items}, nil
+}
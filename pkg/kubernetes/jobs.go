@@ -0,0 +1,28 @@
+package kubernetes
+
+import (
+	"context"
+
+	v1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Jobs gets the list of jobs in a namespace
+func (k *API) Jobs(ctx context.Context, namespace string) (*v1.JobList, error) {
+	if k.informers != nil {
+		return k.informers.jobList(namespace)
+	}
+	items, err := snapshotList(ctx, k, "jobs", namespace, func(ctx context.Context, namespace string) ([]v1.Job, error) {
+		return list(ctx, k, metav1.ListOptions{}, func(ctx context.Context, options metav1.ListOptions) ([]v1.Job, string, error) {
+			page, err := k.Client.BatchV1().Jobs(namespace).List(ctx, options)
+			if err != nil {
+				return nil, "", err
+			}
+			return page.Items, page.Continue, nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &v1.JobList{Items: items}, nil
+}
@@ -0,0 +1,102 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/logger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// ErrLeadershipLost is the cause of the context handed to the leader when the Lease could not be renewed
+var ErrLeadershipLost = errors.New("leader election lost")
+
+// LeaderElectionConfig configures the Lease used to elect the replica that is allowed to scan
+type LeaderElectionConfig struct {
+	LeaseName      string
+	LeaseNamespace string
+	Identity       string
+	LeaseDuration  time.Duration
+	RenewDeadline  time.Duration
+	RetryPeriod    time.Duration
+}
+
+// RunWithLeaderElection blocks until this replica holds the Lease and then calls run. The context
+// handed to run is cancelled with ErrLeadershipLost as soon as the Lease cannot be renewed. The Lease
+// is released once run returned, so another replica can take over without waiting for it to expire.
+func (k *API) RunWithLeaderElection(ctx context.Context, config LeaderElectionConfig, run func(ctx context.Context)) error {
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      config.LeaseName,
+			Namespace: config.LeaseNamespace,
+		},
+		Client: k.Client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: config.Identity,
+		},
+	}
+
+	leading := make(chan context.Context, 1)
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		ReleaseOnCancel: true,
+		LeaseDuration:   config.LeaseDuration,
+		RenewDeadline:   config.RenewDeadline,
+		RetryPeriod:     config.RetryPeriod,
+		Name:            config.LeaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leaderCtx context.Context) {
+				leading <- leaderCtx
+			},
+			OnStoppedLeading: func() {
+				logger.Infof("Stopped leading lease '%s/%s' as '%s'", config.LeaseNamespace, config.LeaseName, config.Identity)
+			},
+			OnNewLeader: func(identity string) {
+				if identity != config.Identity {
+					logger.Infof("Lease '%s/%s' is held by '%s', waiting for leadership", config.LeaseNamespace, config.LeaseName, identity)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	electionCtx, stopElection := context.WithCancel(ctx)
+	defer stopElection()
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		elector.Run(electionCtx)
+	}()
+
+	select {
+	case <-stopped:
+		// ctx was cancelled before the Lease could be acquired
+		return nil
+	case leaderCtx := <-leading:
+		logger.Infof("Acquired lease '%s/%s' as '%s'", config.LeaseNamespace, config.LeaseName, config.Identity)
+		runCtx, cancel := context.WithCancelCause(ctx)
+		go func() {
+			select {
+			case <-leaderCtx.Done():
+				cancel(ErrLeadershipLost)
+			case <-runCtx.Done():
+			}
+		}()
+		run(runCtx)
+		lost := errors.Is(context.Cause(runCtx), ErrLeadershipLost)
+		cancel(nil)
+
+		// Release the Lease and wait for the elector to hand it over
+		stopElection()
+		<-stopped
+		if lost {
+			return ErrLeadershipLost
+		}
+		return nil
+	}
+}
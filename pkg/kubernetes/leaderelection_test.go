@@ -0,0 +1,71 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+)
+
+func testLeaderElectionConfig(identity string) LeaderElectionConfig {
+	return LeaderElectionConfig{
+		LeaseName:      "leanix-k8s-connector",
+		LeaseNamespace: "leanix",
+		Identity:       identity,
+		LeaseDuration:  2 * time.Second,
+		RenewDeadline:  1 * time.Second,
+		RetryPeriod:    100 * time.Millisecond,
+	}
+}
+
+func TestRunWithLeaderElection(t *testing.T) {
+	logger.Init()
+	k := API{
+		Client: fake.NewSimpleClientset(),
+	}
+
+	ran := false
+	err := k.RunWithLeaderElection(context.Background(), testLeaderElectionConfig("replica-1"), func(ctx context.Context) {
+		ran = true
+		assert.NoError(t, ctx.Err())
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, ran)
+	lease, err := k.Client.CoordinationV1().Leases("leanix").Get(context.Background(), "leanix-k8s-connector", metav1.GetOptions{})
+	assert.NoError(t, err)
+	// the lease is released after the run
+	assert.Empty(t, pointer.StringDeref(lease.Spec.HolderIdentity, ""))
+}
+
+func TestRunWithLeaderElection_heldByOtherReplica(t *testing.T) {
+	logger.Init()
+	now := metav1.NewMicroTime(time.Now())
+	k := API{
+		Client: fake.NewSimpleClientset(&coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: "leanix-k8s-connector", Namespace: "leanix"},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       pointer.String("replica-2"),
+				LeaseDurationSeconds: pointer.Int32(60),
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	ran := false
+	err := k.RunWithLeaderElection(ctx, testLeaderElectionConfig("replica-1"), func(ctx context.Context) {
+		ran = true
+	})
+
+	assert.NoError(t, err)
+	assert.False(t, ran)
+}
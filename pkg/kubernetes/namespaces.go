@@ -7,10 +7,41 @@ import (
 )
 
 // Namespaces gets the list of blacklisted namespaces
-func (k *API) Namespaces(blacklistedNamespaces []string) (*v1.NamespaceList, error) {
-	namespaces, err := k.Client.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{FieldSelector: NamespaceBlacklistFieldSelector(blacklistedNamespaces)})
+func (k *API) Namespaces(ctx context.Context, blacklistedNamespaces []string) (*v1.NamespaceList, error) {
+	if k.informers != nil {
+		return k.informers.namespaceList(blacklistedNamespaces)
+	}
+	if k.snapshot != nil {
+		// the snapshot keeps all namespaces, the blacklisted ones are filtered here
+		items, err := snapshotList(ctx, k, "namespaces", "", func(ctx context.Context, _ string) ([]v1.Namespace, error) {
+			return k.listNamespaces(ctx, metav1.ListOptions{})
+		})
+		if err != nil {
+			return nil, err
+		}
+		namespaces := &v1.NamespaceList{Items: make([]v1.Namespace, 0, len(items))}
+		for _, item := range items {
+			if !contains(blacklistedNamespaces, item.Name) {
+				namespaces.Items = append(namespaces.Items, item)
+			}
+		}
+		return namespaces, nil
+	}
+	items, err := tracedList(ctx, "namespaces", "", func(ctx context.Context, _ string) ([]v1.Namespace, error) {
+		return k.listNamespaces(ctx, metav1.ListOptions{FieldSelector: NamespaceBlacklistFieldSelector(blacklistedNamespaces)})
+	})
 	if err != nil {
 		return nil, err
 	}
-	return namespaces, err
+	return &v1.NamespaceList{Items: items}, err
+}
+
+func (k *API) listNamespaces(ctx context.Context, options metav1.ListOptions) ([]v1.Namespace, error) {
+	return list(ctx, k, options, func(ctx context.Context, options metav1.ListOptions) ([]v1.Namespace, string, error) {
+		page, err := k.Client.CoreV1().Namespaces().List(ctx, options)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Items, page.Continue, nil
+	})
 }
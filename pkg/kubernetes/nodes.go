@@ -8,17 +8,25 @@ import (
 )
 
 // Nodes gets the list of worker nodes (kubelets)
-func (k *API) Nodes() (*corev1.NodeList, error) {
-	nodes, err := k.Client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+func (k *API) Nodes(ctx context.Context) (*corev1.NodeList, error) {
+	items, err := snapshotList(ctx, k, "nodes", "", func(ctx context.Context, _ string) ([]corev1.Node, error) {
+		return list(ctx, k, metav1.ListOptions{}, func(ctx context.Context, options metav1.ListOptions) ([]corev1.Node, string, error) {
+			page, err := k.Client.CoreV1().Nodes().List(ctx, options)
+			if err != nil {
+				return nil, "", err
+			}
+			return page.Items, page.Continue, nil
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
-	return nodes, nil
+	return &corev1.NodeList{Items: items}, nil
 }
 
 // NodesByName returns a list of nodes for the given node names
-func (k *API) NodesByName(nodeNames *set.String) (*[]corev1.Node, error) {
-	nodes, err := k.Nodes()
+func (k *API) NodesByName(ctx context.Context, nodeNames *set.String) (*[]corev1.Node, error) {
+	nodes, err := k.Nodes(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,8 @@
 package kubernetes
 
 import (
+	"context"
+
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -40,7 +42,7 @@ func TestNodes(t *testing.T) {
 		Client: fake.NewSimpleClientset(dummyNodes...),
 	}
 
-	nodes, err := k.Nodes()
+	nodes, err := k.Nodes(context.Background())
 	if err != nil {
 		t.Error(err)
 	}
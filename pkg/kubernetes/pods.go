@@ -0,0 +1,28 @@
+package kubernetes
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Pods gets the list of pods in a namespace
+func (k *API) Pods(ctx context.Context, namespace string) (*corev1.PodList, error) {
+	if k.informers != nil {
+		return k.informers.podList(namespace)
+	}
+	items, err := snapshotList(ctx, k, "pods", namespace, func(ctx context.Context, namespace string) ([]corev1.Pod, error) {
+		return list(ctx, k, metav1.ListOptions{}, func(ctx context.Context, options metav1.ListOptions) ([]corev1.Pod, string, error) {
+			page, err := k.Client.CoreV1().Pods(namespace).List(ctx, options)
+			if err != nil {
+				return nil, "", err
+			}
+			return page.Items, page.Continue, nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.PodList{Items: items}, nil
+}
@@ -0,0 +1,28 @@
+package kubernetes
+
+import (
+	"context"
+
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReplicaSets gets the list of replicaSets in a namespace
+func (k *API) ReplicaSets(ctx context.Context, namespace string) (*v1.ReplicaSetList, error) {
+	if k.informers != nil {
+		return k.informers.replicaSetList(namespace)
+	}
+	items, err := snapshotList(ctx, k, "replicasets", namespace, func(ctx context.Context, namespace string) ([]v1.ReplicaSet, error) {
+		return list(ctx, k, metav1.ListOptions{}, func(ctx context.Context, options metav1.ListOptions) ([]v1.ReplicaSet, string, error) {
+			page, err := k.Client.AppsV1().ReplicaSets(namespace).List(ctx, options)
+			if err != nil {
+				return nil, "", err
+			}
+			return page.Items, page.Continue, nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &v1.ReplicaSetList{Items: items}, nil
+}
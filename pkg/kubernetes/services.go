@@ -2,16 +2,49 @@ package kubernetes
 
 import (
 	"context"
-	corev1 "k8s.io/api/core/v1"
+	"sort"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // Services gets the list of services in a namespace
-func (k *API) Services(namespace string) (*corev1.ServiceList, error) {
-	services, err := k.Client.CoreV1().Services(namespace).List(context.Background(), metav1.ListOptions{})
+func (k *API) Services(ctx context.Context, namespace string) (*corev1.ServiceList, error) {
+	if k.informers != nil {
+		return k.informers.serviceList(namespace)
+	}
+	items, err := snapshotList(ctx, k, "services", namespace, func(ctx context.Context, namespace string) ([]corev1.Service, error) {
+		return list(ctx, k, metav1.ListOptions{}, func(ctx context.Context, options metav1.ListOptions) ([]corev1.Service, string, error) {
+			page, err := k.Client.CoreV1().Services(namespace).List(ctx, options)
+			if err != nil {
+				return nil, "", err
+			}
+			return page.Items, page.Continue, nil
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
-	return services, nil
+	return &corev1.ServiceList{Items: items}, nil
+}
+
+// SelectingServices returns the services in the namespace of a workload which select the pods of
+// its template, ordered by name. Services without a selector select no pods, their endpoints are
+// managed by hand.
+func SelectingServices(services *corev1.ServiceList, namespace string, template corev1.PodTemplateSpec) []corev1.Service {
+	var matching []corev1.Service
+	podLabels := labels.Set(template.Labels)
+	for _, service := range services.Items {
+		if service.Namespace != namespace || len(service.Spec.Selector) == 0 {
+			continue
+		}
+		if labels.SelectorFromSet(service.Spec.Selector).Matches(podLabels) {
+			matching = append(matching, service)
+		}
+	}
+	sort.Slice(matching, func(a, b int) bool {
+		return matching[a].Name < matching[b].Name
+	})
+	return matching
 }
@@ -0,0 +1,43 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func selectingService(name string, namespace string, selector map[string]string) corev1.Service {
+	return corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       corev1.ServiceSpec{Selector: selector},
+	}
+}
+
+func TestSelectingServices(t *testing.T) {
+	headless := selectingService("shop-headless", "web", map[string]string{"app": "shop"})
+	headless.Spec.ClusterIP = corev1.ClusterIPNone
+	services := &corev1.ServiceList{Items: []corev1.Service{
+		selectingService("shop", "web", map[string]string{"app": "shop", "tier": "frontend"}),
+		headless,
+		// selects only some of the pods' labels of another tier
+		selectingService("shop-backend", "web", map[string]string{"app": "shop", "tier": "backend"}),
+		// the selector shares no label with the pods
+		selectingService("cache", "web", map[string]string{"app": "cache"}),
+		// services select pods of their own namespace only
+		selectingService("shop", "other", map[string]string{"app": "shop"}),
+		// services without a selector have their endpoints managed by hand
+		selectingService("external", "web", nil),
+	}}
+	template := corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "shop", "tier": "frontend", "version": "v2"}}}
+
+	matching := SelectingServices(services, "web", template)
+
+	names := []string{}
+	for _, service := range matching {
+		names = append(names, service.Name)
+	}
+	assert.Equal(t, []string{"shop", "shop-headless"}, names)
+	assert.Empty(t, SelectingServices(services, "web", corev1.PodTemplateSpec{}))
+}
@@ -0,0 +1,116 @@
+package kubernetes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"k8s.io/client-go/rest"
+)
+
+// Snapshot keeps the first cluster wide list of every resource, so all mappers of a scan work on
+// the same state of the cluster and every resource is listed only once
+type Snapshot struct {
+	mu    sync.Mutex
+	lists map[string]interface{}
+	// listed is the time spent listing the resources of the snapshot
+	listed time.Duration
+}
+
+// WithSnapshot returns a copy of the api which lists every resource once, cluster wide, on first
+// use and answers all later list calls from that list. An api which already has a snapshot is
+// returned as it is.
+func (k *API) WithSnapshot() *API {
+	if k.snapshot != nil {
+		return k
+	}
+	return &API{
+		Client:         k.Client,
+		Dynamic:        k.Dynamic,
+		PageSize:       k.PageSize,
+		RequestTimeout: k.RequestTimeout,
+		informers:      k.informers,
+		snapshot:       &Snapshot{lists: map[string]interface{}{}},
+	}
+}
+
+// SharedSnapshot returns a GetKubernetesAPI which creates the api only once and hands out the same
+// snapshot of it to every caller, e.g. to the scans of several configurations
+func SharedSnapshot(getKubernetesAPI GetKubernetesAPI) GetKubernetesAPI {
+	var once sync.Once
+	var api *API
+	var err error
+	return func(config *rest.Config) (*API, error) {
+		once.Do(func() {
+			api, err = getKubernetesAPI(config)
+			if err == nil {
+				api = api.WithSnapshot()
+			}
+		})
+		return api, err
+	}
+}
+
+// ListDuration returns the time the api spent so far listing the resources of its snapshot, 0 for
+// an api without a snapshot
+func (k *API) ListDuration() time.Duration {
+	if k.snapshot == nil {
+		return 0
+	}
+	k.snapshot.mu.Lock()
+	defer k.snapshot.mu.Unlock()
+	return k.snapshot.listed
+}
+
+// namespaced is implemented by the pointers to all Kubernetes objects
+type namespaced[T any] interface {
+	*T
+	GetNamespace() string
+}
+
+// listAllFunc lists all items of a resource in a namespace, or in all namespaces for ""
+type listAllFunc[T any] func(ctx context.Context, namespace string) ([]T, error)
+
+// snapshotList lists the items of a resource in the namespace, from the snapshot of the api if
+// it has one. A failed list is not kept, the next call lists the resource again.
+func snapshotList[T any, PT namespaced[T]](ctx context.Context, k *API, resource string, namespace string, listAll listAllFunc[T]) ([]T, error) {
+	if k.snapshot == nil {
+		return tracedList(ctx, resource, namespace, listAll)
+	}
+	k.snapshot.mu.Lock()
+	all, ok := k.snapshot.lists[resource].([]T)
+	if !ok {
+		var err error
+		started := time.Now()
+		all, err = tracedList(ctx, resource, "", listAll)
+		k.snapshot.listed += time.Since(started)
+		if err != nil {
+			k.snapshot.mu.Unlock()
+			return nil, err
+		}
+		k.snapshot.lists[resource] = all
+	}
+	k.snapshot.mu.Unlock()
+
+	items := make([]T, 0, len(all))
+	for i := range all {
+		if namespace == "" || PT(&all[i]).GetNamespace() == namespace {
+			items = append(items, all[i])
+		}
+	}
+	return items, nil
+}
+
+// tracedList lists the items of a resource in a span of its own
+func tracedList[T any](ctx context.Context, resource string, namespace string, listAll listAllFunc[T]) (items []T, err error) {
+	ctx, span := tracing.Start(ctx, "kubernetes list "+resource,
+		attribute.String("k8s.resource", resource),
+		attribute.String("k8s.namespace.name", namespace))
+	defer func() {
+		span.SetAttributes(attribute.Int("k8s.items", len(items)))
+		tracing.End(span, err)
+	}()
+	return listAll(ctx, namespace)
+}
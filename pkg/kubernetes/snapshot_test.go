@@ -0,0 +1,125 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// countLists counts the list requests per resource
+func countLists(client *fake.Clientset) map[string]int {
+	lists := map[string]int{}
+	client.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		lists[action.GetResource().Resource]++
+		return false, nil, nil
+	})
+	return lists
+}
+
+func TestWithSnapshot(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "web"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"}},
+	)
+	lists := countLists(client)
+	k := (&API{Client: client}).WithSnapshot()
+	ctx := context.Background()
+
+	all, err := k.Deployments(ctx, "")
+	assert.NoError(t, err)
+	web, err := k.Deployments(ctx, "web")
+	assert.NoError(t, err)
+	namespaces, err := k.Namespaces(ctx, []string{"kube-system"})
+	assert.NoError(t, err)
+	allNamespaces, err := k.Namespaces(ctx, nil)
+	assert.NoError(t, err)
+	_, err = k.Nodes(ctx)
+	assert.NoError(t, err)
+	nodes, err := k.Nodes(ctx)
+	assert.NoError(t, err)
+
+	assert.Len(t, all.Items, 2)
+	assert.Len(t, web.Items, 1)
+	assert.Equal(t, "shop", web.Items[0].Name)
+	assert.Len(t, namespaces.Items, 1)
+	assert.Equal(t, "web", namespaces.Items[0].Name)
+	assert.Len(t, allNamespaces.Items, 2)
+	assert.Len(t, nodes.Items, 1)
+	assert.Equal(t, map[string]int{"deployments": 1, "namespaces": 1, "nodes": 1}, lists)
+}
+
+func TestWithSnapshot_failedList(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "web"}})
+	failures := 1
+	client.PrependReactor("list", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if failures > 0 {
+			failures--
+			return true, nil, errors.New("connection refused")
+		}
+		return false, nil, nil
+	})
+	lists := countLists(client)
+	k := (&API{Client: client}).WithSnapshot()
+
+	_, err := k.Services(context.Background(), "")
+	assert.Error(t, err)
+	services, err := k.Services(context.Background(), "web")
+	assert.NoError(t, err)
+
+	assert.Len(t, services.Items, 1)
+	assert.Equal(t, 2, lists["services"])
+}
+
+func TestListDuration(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}})
+	client.PrependReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		time.Sleep(10 * time.Millisecond)
+		return false, nil, nil
+	})
+	k := (&API{Client: client}).WithSnapshot()
+
+	_, err := k.Nodes(context.Background())
+	assert.NoError(t, err)
+	listed := k.ListDuration()
+	_, err = k.Nodes(context.Background())
+	assert.NoError(t, err)
+
+	assert.GreaterOrEqual(t, listed, 10*time.Millisecond)
+	assert.Equal(t, listed, k.ListDuration())
+	assert.Zero(t, (&API{Client: client}).ListDuration())
+}
+
+func TestSharedSnapshot(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}})
+	lists := countLists(client)
+	created := 0
+	getKubernetesAPI := SharedSnapshot(func(*rest.Config) (*API, error) {
+		created++
+		return &API{Client: client}, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		k, err := getKubernetesAPI(nil)
+		assert.NoError(t, err)
+		// scans take a snapshot of their own api, which keeps the shared one
+		nodes, err := k.WithSnapshot().Nodes(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, nodes.Items, 1)
+	}
+
+	assert.Equal(t, 1, created)
+	assert.Equal(t, 1, lists["nodes"])
+}
@@ -8,10 +8,21 @@ import (
 )
 
 // Cronjobs gets the list of cronjobs in a namespace
-func (k *API) StatefulSets(namespace string) (*v1.StatefulSetList, error) {
-	statefulSets, err := k.Client.AppsV1().StatefulSets(namespace).List(context.Background(), metav1.ListOptions{})
+func (k *API) StatefulSets(ctx context.Context, namespace string) (*v1.StatefulSetList, error) {
+	if k.informers != nil {
+		return k.informers.statefulSetList(namespace)
+	}
+	items, err := snapshotList(ctx, k, "statefulsets", namespace, func(ctx context.Context, namespace string) ([]v1.StatefulSet, error) {
+		return list(ctx, k, metav1.ListOptions{}, func(ctx context.Context, options metav1.ListOptions) ([]v1.StatefulSet, string, error) {
+			page, err := k.Client.AppsV1().StatefulSets(namespace).List(ctx, options)
+			if err != nil {
+				return nil, "", err
+			}
+			return page.Items, page.Continue, nil
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
-	return statefulSets, nil
+	return &v1.StatefulSetList{Items: items}, nil
 }
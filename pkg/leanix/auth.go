@@ -3,7 +3,6 @@ package leanix
 import (
 	"encoding/json"
 	"fmt"
-	"github.com/leanix/leanix-k8s-connector/pkg/logger"
 	"io/ioutil"
 	"net/http"
 	"strings"
@@ -25,30 +24,34 @@ type SyncRunResponse struct {
 	Description string `json:"description"`
 }
 
-// Authenticate uses token to authenticate against MTM and response with access_token
-func Authenticate(fqdn string, token string) (string, error) {
+func authenticate(client *http.Client, tokenUrl string, token string) (AuthResponse, error) {
+	authResponse := AuthResponse{}
 	body := strings.NewReader("grant_type=client_credentials")
-	req, err := http.NewRequest("POST", "https://"+fqdn+"/services/mtm/v1/oauth2/token", body)
-	logger.Infof("LeanIX integration api fqdn : %s", fqdn)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req, err := http.NewRequest("POST", tokenUrl, body)
 	if err != nil {
-		return "", err
+		return authResponse, err
 	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.SetBasicAuth("apitoken", token)
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return "", err
+		return authResponse, err
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
 		err := fmt.Errorf("Integration API authentication failed: %s", resp.Status)
-		return "", err
+		return authResponse, err
 	}
-	defer resp.Body.Close()
 	responseData, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return authResponse, err
 	}
-	authResponse := AuthResponse{}
-	json.Unmarshal(responseData, &authResponse)
-	return authResponse.AccessToken, nil
+	err = json.Unmarshal(responseData, &authResponse)
+	if err != nil {
+		return authResponse, fmt.Errorf("Integration API authentication returned an invalid response: %w", err)
+	}
+	if authResponse.AccessToken == "" {
+		return authResponse, fmt.Errorf("Integration API authentication returned no access token")
+	}
+	return authResponse, nil
 }
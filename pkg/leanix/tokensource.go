@@ -0,0 +1,99 @@
+package leanix
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/logger"
+)
+
+// TokenRefreshMargin is the time before its expiry a cached access token is refreshed, so a
+// request does not start with a token that expires while it is in flight
+const TokenRefreshMargin = 5 * time.Minute
+
+// DefaultTokenLifetime is assumed for access tokens MTM returns without their lifetime
+const DefaultTokenLifetime = 10 * time.Minute
+
+// TokenSource provides the bearer token for requests to LeanIX services
+type TokenSource interface {
+	// Token returns a valid access token
+	Token() (string, error)
+	// Invalidate drops the given token after a service rejected it, the next call to Token
+	// authenticates again
+	Invalidate(token string)
+}
+
+type mtmTokenSource struct {
+	client   *http.Client
+	tokenUrl string
+	apiToken string
+	now      func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	refreshAt time.Time
+}
+
+// NewTokenSource creates a TokenSource that exchanges the api token for an access token at MTM,
+// caches it and fetches a new one shortly before it expires
+func NewTokenSource(client *http.Client, fqdn string, apiToken string) TokenSource {
+	protocol := ""
+	if !strings.Contains(fqdn, "http") {
+		protocol = "https://"
+	}
+	return &mtmTokenSource{
+		client:   client,
+		tokenUrl: fmt.Sprintf("%s%s/services/mtm/v1/oauth2/token", protocol, fqdn),
+		apiToken: apiToken,
+		now:      time.Now,
+	}
+}
+
+func (s *mtmTokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && s.now().Before(s.refreshAt) {
+		return s.token, nil
+	}
+	authResponse, err := authenticate(s.client, s.tokenUrl, s.apiToken)
+	if err != nil {
+		return "", err
+	}
+	lifetime := time.Duration(authResponse.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = DefaultTokenLifetime
+	}
+	margin := TokenRefreshMargin
+	if lifetime < 2*margin {
+		margin = lifetime / 2
+	}
+	s.token = authResponse.AccessToken
+	s.refreshAt = s.now().Add(lifetime - margin)
+	logger.Debugf("Fetched access token valid for %v", lifetime)
+	return s.token, nil
+}
+
+func (s *mtmTokenSource) Invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// another request may have refreshed the token already
+	if s.token == token {
+		s.token = ""
+	}
+}
+
+type staticTokenSource string
+
+// StaticTokenSource always returns the given token, e.g. for tests or tokens managed elsewhere
+func StaticTokenSource(token string) TokenSource {
+	return staticTokenSource(token)
+}
+
+func (s staticTokenSource) Token() (string, error) {
+	return string(s), nil
+}
+
+func (s staticTokenSource) Invalidate(string) {}
@@ -0,0 +1,161 @@
+package leanix
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+// mtmServer hands out access-token-1, access-token-2, ... valid for expiresIn seconds
+func mtmServer(t *testing.T, requests *int32, expiresIn int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/services/mtm/v1/oauth2/token", req.URL.Path)
+		user, password, ok := req.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "apitoken", user)
+		assert.Equal(t, "api-token", password)
+		request := atomic.AddInt32(requests, 1)
+		fmt.Fprintf(rw, `{"access_token": "access-token-%d", "token_type": "bearer", "expires_in": %d}`, request, expiresIn)
+	}))
+}
+
+func TestTokenSource_cached(t *testing.T) {
+	logger.Init()
+	var requests int32
+	server := mtmServer(t, &requests, 3600)
+	defer server.Close()
+	source := NewTokenSource(server.Client(), server.URL, "api-token")
+
+	first, err := source.Token()
+	assert.NoError(t, err)
+	second, err := source.Token()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "access-token-1", first)
+	assert.Equal(t, "access-token-1", second)
+	assert.Equal(t, int32(1), requests)
+}
+
+func TestTokenSource_refreshedBeforeExpiry(t *testing.T) {
+	logger.Init()
+	var requests int32
+	server := mtmServer(t, &requests, 3600)
+	defer server.Close()
+	source := NewTokenSource(server.Client(), server.URL, "api-token").(*mtmTokenSource)
+	now := time.Now()
+	source.now = func() time.Time { return now }
+
+	_, err := source.Token()
+	assert.NoError(t, err)
+	now = now.Add(time.Hour - TokenRefreshMargin - time.Second)
+	token, err := source.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "access-token-1", token)
+
+	// within the refresh margin a new token is fetched although the old one is still valid
+	now = now.Add(2 * time.Second)
+	token, err = source.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "access-token-2", token)
+}
+
+func TestTokenSource_shortLivedToken(t *testing.T) {
+	logger.Init()
+	var requests int32
+	server := mtmServer(t, &requests, 60)
+	defer server.Close()
+	source := NewTokenSource(server.Client(), server.URL, "api-token").(*mtmTokenSource)
+	now := time.Now()
+	source.now = func() time.Time { return now }
+
+	_, err := source.Token()
+	assert.NoError(t, err)
+	// tokens living shorter than twice the margin are refreshed after half their lifetime
+	now = now.Add(31 * time.Second)
+	token, err := source.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "access-token-2", token)
+}
+
+func TestTokenSource_invalidate(t *testing.T) {
+	logger.Init()
+	var requests int32
+	server := mtmServer(t, &requests, 3600)
+	defer server.Close()
+	source := NewTokenSource(server.Client(), server.URL, "api-token")
+
+	token, err := source.Token()
+	assert.NoError(t, err)
+	source.Invalidate(token)
+	refreshed, err := source.Token()
+	assert.NoError(t, err)
+	// a stale token does not drop the refreshed one
+	source.Invalidate(token)
+	cached, err := source.Token()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "access-token-2", refreshed)
+	assert.Equal(t, "access-token-2", cached)
+	assert.Equal(t, int32(2), requests)
+}
+
+func TestTokenSource_authenticationFailed(t *testing.T) {
+	logger.Init()
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	source := NewTokenSource(server.Client(), server.URL, "api-token")
+
+	_, err := source.Token()
+
+	assert.EqualError(t, err, "Integration API authentication failed: 401 Unauthorized")
+}
+
+func TestTokenSource_unknownLifetime(t *testing.T) {
+	logger.Init()
+	var requests int32
+	server := mtmServer(t, &requests, 0)
+	defer server.Close()
+	source := NewTokenSource(server.Client(), server.URL, "api-token").(*mtmTokenSource)
+	now := time.Now()
+	source.now = func() time.Time { return now }
+
+	_, err := source.Token()
+	assert.NoError(t, err)
+	token, err := source.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "access-token-1", token)
+
+	now = now.Add(DefaultTokenLifetime)
+	token, err = source.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "access-token-2", token)
+}
+
+func TestTokenSource_invalidResponse(t *testing.T) {
+	logger.Init()
+	for name, response := range map[string]string{
+		"not json":        `<html>maintenance</html>`,
+		"no access token": `{"token_type": "bearer", "expires_in": 3600}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				fmt.Fprint(rw, response)
+			}))
+			defer server.Close()
+			source := NewTokenSource(server.Client(), server.URL, "api-token")
+
+			token, err := source.Token()
+
+			assert.Error(t, err)
+			assert.Empty(t, token)
+		})
+	}
+}
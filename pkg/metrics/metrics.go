@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+const namespace = "leanix_k8s_connector"
+
+// Phases of a scan
+const (
+	PhaseConfig  string = "config"
+	PhaseListing string = "listing"
+	PhaseMapping string = "mapping"
+	PhaseDiffing string = "diffing"
+	PhasePosting string = "posting"
+)
+
+// Actions of the ECST events
+const (
+	ActionCreated string = "created"
+	ActionUpdated string = "updated"
+	ActionDeleted string = "deleted"
+)
+
+// Registry holds the metrics of the connector, it is served on the metrics endpoint and pushed to
+// the Pushgateway
+var Registry = prometheus.NewRegistry()
+
+var (
+	phaseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "scan_phase_duration_seconds",
+		Help:      "Duration of the phases of a scan.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 15),
+	}, []string{"phase"})
+	discoveredItems = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "discovered_items",
+		Help:      "Number of items discovered by the last scan of a cluster, by type.",
+	}, []string{"cluster", "type"})
+	events = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ecst_events_total",
+		Help:      "Number of ECST events posted to Iris, by action.",
+	}, []string{"action"})
+	irisRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "iris_request_duration_seconds",
+		Help:      "Duration of the requests to Iris, by endpoint and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "endpoint", "code"})
+	lastSuccessfulScan = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_successful_scan_timestamp_seconds",
+		Help:      "Unix time of the last successful scan of a configuration.",
+	}, []string{"configuration"})
+)
+
+func init() {
+	Registry.MustRegister(
+		phaseDuration,
+		discoveredItems,
+		events,
+		irisRequestDuration,
+		lastSuccessfulScan,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// ObservePhase records the duration of a phase of a scan
+func ObservePhase(phase string, duration time.Duration) {
+	phaseDuration.WithLabelValues(phase).Observe(duration.Seconds())
+}
+
+// SetDiscoveredItems replaces the number of discovered items of the cluster for the given types
+func SetDiscoveredItems(cluster string, counts map[string]int) {
+	for itemType, count := range counts {
+		discoveredItems.WithLabelValues(cluster, itemType).Set(float64(count))
+	}
+}
+
+// CountEvents adds the posted ECST events by action
+func CountEvents(created int, updated int, deleted int) {
+	events.WithLabelValues(ActionCreated).Add(float64(created))
+	events.WithLabelValues(ActionUpdated).Add(float64(updated))
+	events.WithLabelValues(ActionDeleted).Add(float64(deleted))
+}
+
+// ObserveIrisRequest records the duration of a request to an endpoint of Iris. A request which got
+// no response is recorded with the code "error".
+func ObserveIrisRequest(method string, endpoint string, resp *http.Response, duration time.Duration) {
+	code := "error"
+	if resp != nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	irisRequestDuration.WithLabelValues(method, endpoint, code).Observe(duration.Seconds())
+}
+
+// SetLastSuccessfulScan records the time of the last successful scan of the configuration
+func SetLastSuccessfulScan(configurationName string, at time.Time) {
+	lastSuccessfulScan.WithLabelValues(configurationName).Set(float64(at.Unix()))
+}
+
+// Serve serves the metrics on /metrics of the address until ctx is done
+func Serve(ctx context.Context, address string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry}))
+	server := &http.Server{Addr: address, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	logger.Infof("Serving metrics on %s/metrics", address)
+	err := server.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Push replaces the metrics of the job on the Pushgateway at url, used when the connector runs as
+// a CronJob which exits before it can be scraped
+func Push(url string, job string) error {
+	return push.New(url, job).Gatherer(Registry).Push()
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObserveIrisRequest(t *testing.T) {
+	irisRequestDuration.Reset()
+
+	ObserveIrisRequest("POST", "ecst", &http.Response{StatusCode: 200}, time.Second)
+	ObserveIrisRequest("POST", "ecst", &http.Response{StatusCode: 200}, time.Second)
+	ObserveIrisRequest("POST", "ecst", nil, time.Second)
+
+	assert.Equal(t, 2, testutil.CollectAndCount(irisRequestDuration))
+	assert.Equal(t, uint64(2), histogramCount(t, "POST", "ecst", "200"))
+	assert.Equal(t, uint64(1), histogramCount(t, "POST", "ecst", "error"))
+}
+
+func TestCountEvents(t *testing.T) {
+	events.Reset()
+
+	CountEvents(2, 1, 0)
+	CountEvents(1, 0, 3)
+
+	assert.Equal(t, 3.0, testutil.ToFloat64(events.WithLabelValues(ActionCreated)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(events.WithLabelValues(ActionUpdated)))
+	assert.Equal(t, 3.0, testutil.ToFloat64(events.WithLabelValues(ActionDeleted)))
+}
+
+func TestSetDiscoveredItems(t *testing.T) {
+	discoveredItems.Reset()
+
+	SetDiscoveredItems("aks", map[string]int{"deployment": 3, "cronjob": 1})
+	SetDiscoveredItems("aks", map[string]int{"deployment": 2})
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(discoveredItems.WithLabelValues("aks", "deployment")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(discoveredItems.WithLabelValues("aks", "cronjob")))
+}
+
+func TestPush(t *testing.T) {
+	var path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	SetLastSuccessfulScan("aks-config", time.Unix(1700000000, 0))
+
+	err := Push(server.URL, "leanix-k8s-connector")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/metrics/job/leanix-k8s-connector", path)
+	assert.Contains(t, body, "leanix_k8s_connector_last_successful_scan_timestamp_seconds")
+}
+
+// histogramCount returns the number of observations of the Iris request duration with the labels
+func histogramCount(t *testing.T, method string, endpoint string, code string) uint64 {
+	families, err := Registry.Gather()
+	assert.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "leanix_k8s_connector_iris_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["method"] == method && labels["endpoint"] == endpoint && labels["code"] == code {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
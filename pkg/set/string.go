@@ -1,5 +1,7 @@
 package set
 
+import "sort"
+
 // String is a helper type to represent a set of strings
 type String struct {
 	Map map[string]bool
@@ -17,7 +19,7 @@ func (s *String) Add(i string) {
 	s.Map[i] = true
 }
 
-// Items returns all items in the set as slice
+// Items returns all items in the set as sorted slice
 func (s *String) Items() []string {
 	slice := make([]string, len(s.Map))
 	i := 0
@@ -25,6 +27,7 @@ func (s *String) Items() []string {
 		slice[i] = k
 		i++
 	}
+	sort.Strings(slice)
 	return slice
 }
 
@@ -33,6 +33,15 @@ func TestStringSetList(t *testing.T) {
 	assert.Contains(t, list, "bar")
 }
 
+func TestStringSetList_sorted(t *testing.T) {
+	s := NewStringSet()
+	s.Add("foo")
+	s.Add("bar")
+	s.Add("baz")
+
+	assert.Equal(t, []string{"bar", "baz", "foo"}, s.Items())
+}
+
 func TestStringSetContains_containsString(t *testing.T) {
 	s := NewStringSet()
 	s.Add("foo")
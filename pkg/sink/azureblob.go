@@ -0,0 +1,48 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+)
+
+type azureBlobSink struct {
+	client    *azblob.Client
+	container string
+}
+
+// NewAzureBlobSink writes every run as JSON blob below the name of its configuration into the
+// container of the storage account. An empty endpoint is the public endpoint of the account, the
+// Azurite emulator listens on e.g. http://127.0.0.1:10000/devstoreaccount1.
+func NewAzureBlobSink(accountName string, accountKey string, container string, endpoint string) (Sink, error) {
+	credential, err := azblob.NewSharedKeyCredential(accountName, accountKey)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net/", accountName)
+	}
+	client, err := azblob.NewClientWithSharedKeyCredential(endpoint, credential, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &azureBlobSink{
+		client:    client,
+		container: container,
+	}, nil
+}
+
+func (s *azureBlobSink) Write(ctx context.Context, run Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	contentType := "application/json"
+	_, err = s.client.UploadBuffer(ctx, s.container, run.ConfigurationId+"/"+runName(run), data, &azblob.UploadBufferOptions{
+		HTTPHeaders: &blob.HTTPHeaders{BlobContentType: &contentType},
+	})
+	return err
+}
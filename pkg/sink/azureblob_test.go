@@ -0,0 +1,78 @@
+package sink
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/stretchr/testify/assert"
+)
+
+// Account and key of the Azurite emulator, see
+// https://learn.microsoft.com/azure/storage/common/storage-use-azurite#well-known-storage-account-and-key
+const (
+	azuriteAccountName = "devstoreaccount1"
+	azuriteAccountKey  = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+)
+
+func TestAzureBlobSinkWrite(t *testing.T) {
+	var method, path, authorization, contentType, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		path = r.URL.Path
+		authorization = r.Header.Get("Authorization")
+		contentType = r.Header.Get("x-ms-blob-content-type")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+	blobSink, err := NewAzureBlobSink(azuriteAccountName, azuriteAccountKey, "runs", server.URL+"/"+azuriteAccountName)
+	assert.NoError(t, err)
+
+	err = blobSink.Write(context.Background(), Run{RunId: "runId", ConfigurationId: "configId", StartedAt: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/devstoreaccount1/runs/configId/20240501T123000Z-runId.json", path)
+	assert.True(t, strings.HasPrefix(authorization, "SharedKey devstoreaccount1:"))
+	assert.Equal(t, "application/json", contentType)
+	assert.Contains(t, body, `"runId":"runId"`)
+}
+
+// TestAzureBlobSinkAzurite runs against an Azurite emulator, e.g. started with
+// docker run -p 10000:10000 mcr.microsoft.com/azure-storage/azurite azurite-blob --blobHost 0.0.0.0
+// and AZURITE_BLOB_ENDPOINT=http://127.0.0.1:10000/devstoreaccount1
+func TestAzureBlobSinkAzurite(t *testing.T) {
+	endpoint := os.Getenv("AZURITE_BLOB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("AZURITE_BLOB_ENDPOINT is not set")
+	}
+	ctx := context.Background()
+	credential, err := azblob.NewSharedKeyCredential(azuriteAccountName, azuriteAccountKey)
+	assert.NoError(t, err)
+	client, err := azblob.NewClientWithSharedKeyCredential(endpoint, credential, nil)
+	assert.NoError(t, err)
+	container := "runs-" + time.Now().Format("20060102150405")
+	_, err = client.CreateContainer(ctx, container, nil)
+	assert.NoError(t, err)
+	defer client.DeleteContainer(ctx, container, nil)
+	blobSink, err := NewAzureBlobSink(azuriteAccountName, azuriteAccountKey, container, endpoint)
+	assert.NoError(t, err)
+
+	err = blobSink.Write(ctx, Run{RunId: "runId", ConfigurationId: "configId", StartedAt: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)})
+
+	assert.NoError(t, err)
+	response, err := client.DownloadStream(ctx, container, "configId/20240501T123000Z-runId.json", nil)
+	assert.NoError(t, err)
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"configurationId":"configId"`)
+}
@@ -0,0 +1,72 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type fileSink struct {
+	path      string
+	retention int
+}
+
+// NewFileSink writes every run as JSON file into the directory of its configuration below path
+// and keeps the latest retention runs of each configuration, 0 keeps all of them
+func NewFileSink(path string, retention int) Sink {
+	return &fileSink{
+		path:      path,
+		retention: retention,
+	}
+}
+
+func (s *fileSink) Write(_ context.Context, run Run) error {
+	dir := filepath.Join(s.path, run.ConfigurationId)
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+	// the run is renamed into place, so a crash never leaves a truncated run behind
+	name := runName(run)
+	tmp := filepath.Join(dir, "."+name+".tmp")
+	err = os.WriteFile(tmp, data, 0o644)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmp, filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+	return s.prune(dir)
+}
+
+// prune removes all but the latest runs within the retention from dir
+func (s *fileSink) prune(dir string) error {
+	if s.retention <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	// the entries are sorted by name, i.e. by the time the runs started
+	var runs []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") && strings.HasSuffix(entry.Name(), ".json") {
+			runs = append(runs, entry.Name())
+		}
+	}
+	for i := 0; i < len(runs)-s.retention; i++ {
+		err = os.Remove(filepath.Join(dir, runs[i]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileSinkWrite(t *testing.T) {
+	dir := t.TempDir()
+	run := Run{
+		RunId:           "runId",
+		ConfigurationId: "configId",
+		Cluster:         "prod",
+		StartedAt:       time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+		Items:           []models.DiscoveryEvent{{HeaderProperties: models.HeaderProperties{Id: "item", Type: models.EVENT_TYPE_STATE}}},
+		Changes:         []models.DiscoveryEvent{{HeaderProperties: models.HeaderProperties{Id: "item", Action: models.EventActionCreated}}},
+	}
+
+	err := NewFileSink(dir, 0).Write(context.Background(), run)
+
+	assert.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(dir, "configId", "20240501T123000Z-runId.json"))
+	assert.NoError(t, err)
+	var written Run
+	assert.NoError(t, json.Unmarshal(data, &written))
+	assert.Equal(t, "runId", written.RunId)
+	assert.Equal(t, "prod", written.Cluster)
+	assert.True(t, run.StartedAt.Equal(written.StartedAt))
+	assert.Equal(t, "item", written.Items[0].HeaderProperties.Id)
+	assert.Equal(t, models.EventActionCreated, written.Changes[0].HeaderProperties.Action)
+}
+
+func TestFileSinkRetention(t *testing.T) {
+	dir := t.TempDir()
+	fileSink := NewFileSink(dir, 2)
+	started := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, runId := range []string{"first", "second", "third"} {
+		err := fileSink.Write(context.Background(), Run{RunId: runId, ConfigurationId: "configId", StartedAt: started.Add(time.Duration(i) * time.Hour)})
+		assert.NoError(t, err)
+	}
+	// the runs of other configurations are kept separately
+	err := fileSink.Write(context.Background(), Run{RunId: "other", ConfigurationId: "otherId", StartedAt: started})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"20240501T130000Z-second.json", "20240501T140000Z-third.json"}, names(t, filepath.Join(dir, "configId")))
+	assert.Equal(t, []string{"20240501T120000Z-other.json"}, names(t, filepath.Join(dir, "otherId")))
+}
+
+func names(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
@@ -0,0 +1,49 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leanix/leanix-k8s-connector/pkg/iris/common/models"
+)
+
+// Storage backends a sink can write to
+const (
+	BackendNone      string = "none"
+	BackendFile      string = "file"
+	BackendAzureBlob string = "azureblob"
+)
+
+// Statuses of a run
+const (
+	StatusSuccessful string = "SUCCESSFUL"
+	StatusFailed     string = "FAILED"
+)
+
+// Run is the copy a sink keeps of a run of a configuration
+type Run struct {
+	RunId           string    `json:"runId"`
+	ConfigurationId string    `json:"configurationId"`
+	Cluster         string    `json:"cluster"`
+	StartedAt       time.Time `json:"startedAt"`
+	// Status is StatusSuccessful, or StatusFailed with the Error the run failed with
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// DryRun marks a run whose changes were not posted to Iris
+	DryRun bool `json:"dryRun,omitempty"`
+	// Items are the state events of all discovered items
+	Items []models.DiscoveryEvent `json:"items"`
+	// Changes are the created, updated and deleted events posted to Iris
+	Changes []models.DiscoveryEvent `json:"changes"`
+}
+
+// Sink keeps a copy of every run outside of Iris
+type Sink interface {
+	Write(ctx context.Context, run Run) error
+}
+
+// runName names the copy of a run, so the runs of a configuration sort by the time they started
+func runName(run Run) string {
+	return fmt.Sprintf("%s-%s.json", run.StartedAt.UTC().Format("20060102T150405Z"), run.RunId)
+}
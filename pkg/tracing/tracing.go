@@ -0,0 +1,100 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporters the spans can be sent to
+const (
+	ExporterNone string = ""
+	ExporterOTLP string = "otlp"
+	ExporterFile string = "file"
+)
+
+const (
+	serviceName = "leanix-k8s-connector"
+	tracerName  = "github.com/leanix/leanix-k8s-connector"
+)
+
+// RunIdKey is the attribute holding the run id of a scan
+const RunIdKey = attribute.Key("leanix.run_id")
+
+// Setup installs the global tracer provider with the given exporter and the W3C trace context
+// propagator. The OTLP exporter sends the spans over HTTP to the endpoint, or to the endpoint of the
+// OTEL_EXPORTER_OTLP_* environment variables if it is empty. The file exporter writes the spans as
+// JSON to the file. The returned shutdown flushes the remaining spans.
+func Setup(ctx context.Context, exporter string, endpoint string, file string) (func(context.Context) error, error) {
+	var spanExporter sdktrace.SpanExporter
+	closeFile := func() error { return nil }
+	switch exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var options []otlptracehttp.Option
+		if endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(endpoint))
+		}
+		otlpExporter, err := otlptracehttp.New(ctx, options...)
+		if err != nil {
+			return nil, err
+		}
+		spanExporter = otlpExporter
+	case ExporterFile:
+		f, err := os.Create(file)
+		if err != nil {
+			return nil, err
+		}
+		fileExporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		spanExporter = fileExporter
+		closeFile = f.Close
+	default:
+		return nil, fmt.Errorf("unknown trace exporter '%s'", exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return func(ctx context.Context) error {
+		return errors.Join(provider.Shutdown(ctx), closeFile())
+	}, nil
+}
+
+// Start starts a span as child of the span in ctx
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End records err, if any, on the span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject adds the trace context of ctx to the headers of an outgoing request
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
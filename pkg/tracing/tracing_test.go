@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetup_file(t *testing.T) {
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	defer func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	}()
+	file := filepath.Join(t.TempDir(), "traces.json")
+
+	shutdown, err := Setup(context.Background(), ExporterFile, "", file)
+	assert.NoError(t, err)
+	ctx, span := Start(context.Background(), "Scan", RunIdKey.String("run-1"))
+	_, child := Start(ctx, "kubernetes list pods")
+	child.End()
+	End(span, nil)
+	err = shutdown(context.Background())
+
+	assert.NoError(t, err)
+	traces, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(traces), `"Name":"Scan"`)
+	assert.Contains(t, string(traces), `"Name":"kubernetes list pods"`)
+	assert.Contains(t, string(traces), `"run-1"`)
+}
+
+func TestSetup_none(t *testing.T) {
+	shutdown, err := Setup(context.Background(), ExporterNone, "", "")
+
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestSetup_unknownExporter(t *testing.T) {
+	_, err := Setup(context.Background(), "jaeger", "", "")
+
+	assert.EqualError(t, err, "unknown trace exporter 'jaeger'")
+}
@@ -6,7 +6,9 @@ const (
 	AzureAccountNameFlag             string = "azure-account-name"
 	AzureAccountKeyFlag              string = "azure-account-key"
 	AzureContainerFlag               string = "azure-container"
+	AzureEndpointFlag                string = "azure-endpoint"
 	LocalFilePathFlag                string = "local-file-path"
+	StorageRetentionFlag             string = "storage-retention"
 	VerboseFlag                      string = "verbose"
 	IntegrationAPIDatasourceNameFlag string = "integration-api-datasourcename"
 	IntegrationAPIFqdnFlag           string = "integration-api-fqdn"
@@ -18,4 +20,48 @@ const (
 	LocalFlag                        string = "local"
 	IrisFlag                         string = "enable-iris"
 	ConfigurationNameFlag            string = "configuration-name"
+	ModeFlag                         string = "mode"
+	ResyncPeriodFlag                 string = "resync-period"
+	LeaderElectFlag                  string = "leader-elect"
+	LeaderElectionLeaseNameFlag      string = "leader-election-lease-name"
+	LeaderElectionNamespaceFlag      string = "leader-election-namespace"
+	LeaderElectionIdentityFlag       string = "leader-election-identity"
+	LeaderElectionLeaseDurationFlag  string = "leader-election-lease-duration"
+	LeaderElectionRenewDeadlineFlag  string = "leader-election-renew-deadline"
+	LeaderElectionRetryPeriodFlag    string = "leader-election-retry-period"
+	KubernetesPageSizeFlag           string = "kubernetes-page-size"
+	KubernetesRequestTimeoutFlag     string = "kubernetes-request-timeout"
+	BatchMaxEventsFlag               string = "batch-max-events"
+	BatchMaxBytesFlag                string = "batch-max-bytes"
+	BatchConcurrencyFlag             string = "batch-concurrency"
+	RetryMaxAttemptsFlag             string = "retry-max-attempts"
+	RetryInitialBackoffFlag          string = "retry-initial-backoff"
+	RetryMaxBackoffFlag              string = "retry-max-backoff"
+	DryRunFlag                       string = "dry-run"
+	DryRunOutputFlag                 string = "dry-run-output"
+	MaxDeletionsFlag                 string = "max-deletions"
+	MaxDeletionPercentFlag           string = "max-deletion-percent"
+	MaxDeletionPercentMinKnownFlag   string = "max-deletion-percent-min-known"
+	AllowMassDeletionFlag            string = "allow-mass-deletion"
+	KubeconfigFlag                   string = "kubeconfig"
+	ClustersFileFlag                 string = "clusters-file"
+	ClusterParallelismFlag           string = "cluster-parallelism"
+	MetricsAddressFlag               string = "metrics-address"
+	MetricsPushgatewayFlag           string = "metrics-pushgateway"
+	MetricsJobFlag                   string = "metrics-job"
+	TracingExporterFlag              string = "tracing-exporter"
+	TracingEndpointFlag              string = "tracing-endpoint"
+	TracingFileFlag                  string = "tracing-file"
+	ExportConfigFlag                 string = "config"
+	ExportContextFlag                string = "context"
+	ExportFormatFlag                 string = "format"
+	ExportOutputFlag                 string = "output"
+)
+
+// ExportCommand exports the inventory of a cluster without Iris
+const ExportCommand string = "export"
+
+const (
+	ScanMode  string = "scan"
+	WatchMode string = "watch"
 )